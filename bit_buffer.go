@@ -7,15 +7,17 @@ import (
 
 // BitSet defines an interface that allows manipulation of a bitset.
 type BitSet interface {
-	getBit(i int) bool
+	GetBit(i int) bool
 	set(i int, value bool)
-	len() int
+	Len() int
 }
 
+// BitBuffer is a sequence of bits, which is written with AppendBits and AppendData
+// and read with GetBit or a BitReader.
 type BitBuffer []bool
 
-// len returns the length of the BitBuffer.
-func (b *BitBuffer) len() int {
+// Len returns the length of the BitBuffer.
+func (b *BitBuffer) Len() int {
 	return len(*b)
 }
 
@@ -27,8 +29,8 @@ func (b *BitBuffer) set(i int, value bool) {
 	(*b)[i] = value
 }
 
-// getBit returns the bit at position i in the BitBuffer.
-func (b *BitBuffer) getBit(i int) bool {
+// GetBit returns the bit at position i in the BitBuffer.
+func (b *BitBuffer) GetBit(i int) bool {
 	if i >= len(*b) {
 		return false
 	}
@@ -42,33 +44,34 @@ func (b *BitBuffer) grow(size int) {
 	*b = res
 }
 
-// appendBits appends val as a binary number of length bits to the end of the BitBuffer.
-func (b *BitBuffer) appendBits(val, length int) error {
+// AppendBits appends val as a binary number of length bits to the end of the BitBuffer,
+// most significant bit first. length must be between 0 and 31, and val must fit in length bits.
+func (b *BitBuffer) AppendBits(val, length int) error {
 	if length < 0 || length > 31 || (val>>uint(length)) != 0 {
-		return fmt.Errorf("value out of range")
+		return fmt.Errorf("%w: value out of range", ErrInvalidArgument)
 	}
-	if math.MaxInt32-b.len() < length {
-		return fmt.Errorf("maximum length reached")
+	if math.MaxInt32-b.Len() < length {
+		return fmt.Errorf("%w: maximum length reached", ErrDataTooLong)
 	}
 	for i := length - 1; i >= 0; i-- {
-		b.set(b.len(), getBit(val, i))
+		b.set(b.Len(), getBit(val, i))
 	}
 	return nil
 }
 
-// appendData appends another BitBuffer to this BitBuffer.
-func (b *BitBuffer) appendData(other *BitBuffer) error {
+// AppendData appends another BitBuffer to this BitBuffer.
+func (b *BitBuffer) AppendData(other *BitBuffer) error {
 	if other == nil {
-		return fmt.Errorf("BitBuffer is nil")
+		return fmt.Errorf("%w: BitBuffer is nil", ErrInvalidArgument)
 	}
 
-	if math.MaxInt32-b.len() < other.len() {
-		return fmt.Errorf("maximum length reached")
+	if math.MaxInt32-b.Len() < other.Len() {
+		return fmt.Errorf("%w: maximum length reached", ErrDataTooLong)
 	}
 
-	for i := 0; i < other.len(); i++ {
-		bit := other.getBit(i)
-		b.set(b.len(), bit)
+	for i := 0; i < other.Len(); i++ {
+		bit := other.GetBit(i)
+		b.set(b.Len(), bit)
 	}
 	return nil
 }
@@ -79,3 +82,43 @@ func (b *BitBuffer) clone() *BitBuffer {
 	copy(clone, *b)
 	return &clone
 }
+
+// BitReader reads binary numbers from a BitBuffer, in the order in which AppendBits wrote them.
+type BitReader struct {
+	buf *BitBuffer
+	pos int
+}
+
+// NewBitReader creates a BitReader which reads buf from its first bit.
+func NewBitReader(buf *BitBuffer) *BitReader {
+	if buf == nil {
+		buf = &BitBuffer{}
+	}
+	return &BitReader{buf: buf}
+}
+
+// ReadBits reads the next length bits as a binary number, most significant bit first.
+// length must be between 0 and 31. It returns an error if fewer than length bits are left.
+func (r *BitReader) ReadBits(length int) (int, error) {
+	if length < 0 || length > 31 {
+		return 0, fmt.Errorf("%w: length out of range", ErrInvalidArgument)
+	}
+	if length > r.Remaining() {
+		return 0, fmt.Errorf("%w: %d bits requested, %d bits left", ErrInvalidArgument, length, r.Remaining())
+	}
+
+	val := 0
+	for i := 0; i < length; i++ {
+		val <<= 1
+		if r.buf.GetBit(r.pos) {
+			val |= 1
+		}
+		r.pos++
+	}
+	return val, nil
+}
+
+// Remaining returns the number of bits which are left to be read.
+func (r *BitReader) Remaining() int {
+	return r.buf.Len() - r.pos
+}
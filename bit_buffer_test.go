@@ -10,20 +10,20 @@ var M = 100000
 func TestEquivalence(t *testing.T) {
 	s := BitBuffer{}
 
-	if s.getBit(1) {
+	if s.GetBit(1) {
 		t.Errorf("Expected bit %d not to be set %t, but it is not", 1, true)
 	}
 
 	for j := 2; j < M; j += 13 {
 		s.set(j, true)
-		if !s.getBit(j) {
+		if !s.GetBit(j) {
 			t.Errorf("Expected bit %d to be set %t, but it is not", j, true)
 		}
 	}
 
 	for j := 1; j < M; j += 5 {
 		s.set(j, false)
-		if s.getBit(j) {
+		if s.GetBit(j) {
 			t.Errorf("Expected bit %d to be set %t, but it is not", j, false)
 		}
 	}
@@ -73,9 +73,9 @@ func TestAppendBits(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.original.appendBits(tt.val, tt.length)
+			err := tt.original.AppendBits(tt.val, tt.length)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("appendBits() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("AppendBits() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 
@@ -118,11 +118,39 @@ func TestAppendData(t *testing.T) {
 	}
 
 	for _, tt := range cases {
-		err := tt.ABufferSet.appendData(tt.BBufferSet)
+		err := tt.ABufferSet.AppendData(tt.BBufferSet)
 		if (err != nil) != tt.wantErr {
-			t.Errorf("appendData() error = %v, wantErr %v", err, tt.wantErr)
+			t.Errorf("AppendData() error = %v, wantErr %v", err, tt.wantErr)
 			return
 		}
 		assert.Equal(t, tt.wantData, tt.ABufferSet)
 	}
 }
+
+func TestBitReader(t *testing.T) {
+	bb := &BitBuffer{}
+	assert.NoError(t, bb.AppendBits(5, 3))
+	assert.NoError(t, bb.AppendBits(0x1ABC, 13))
+	assert.NoError(t, bb.AppendBits(0, 0))
+	assert.Equal(t, 16, bb.Len())
+
+	r := NewBitReader(bb)
+	assert.Equal(t, 16, r.Remaining())
+
+	val, err := r.ReadBits(3)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, val)
+
+	val, err = r.ReadBits(13)
+	assert.NoError(t, err)
+	assert.Equal(t, 0x1ABC, val)
+	assert.Equal(t, 0, r.Remaining())
+
+	_, err = r.ReadBits(1)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	_, err = r.ReadBits(32)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	assert.Equal(t, 0, NewBitReader(nil).Remaining())
+}
@@ -0,0 +1,132 @@
+package go_qr
+
+import (
+	"fmt"
+	"image"
+)
+
+// CodewordBit identifies one bit of one codeword in the error correction block structure of a QR code.
+type CodewordBit struct {
+	Block int // Index of the error correction block.
+	Index int // Index of the codeword within the block. Data codewords come before error correction codewords.
+	Bit   int // Bit within the codeword, from 7 (most significant) to 0 (least significant).
+}
+
+// CodewordMap maps the modules of a QR code to the bits of the codewords they carry, and back.
+type CodewordMap struct {
+	numDataCodewords []int              // Number of data codewords of each block.
+	bits             [][]CodewordBit    // Codeword bit of each module, indexed as [y][x]. Block is -1 for modules which carry no codeword.
+	modules          [][][8]image.Point // Modules of each codeword, indexed as [block][index].
+}
+
+// CodewordMap returns the mapping between the modules of the QR code and its codewords,
+// following the block interleaving and the zig-zag placement used when drawing the codewords.
+func (q *QrCode) CodewordMap() *CodewordMap {
+	layout := q.functionLayout()
+	order, numDataCodewords := codewordOrder(q.version, q.errorCorrectionLevel)
+
+	m := &CodewordMap{
+		numDataCodewords: numDataCodewords,
+		bits:             make([][]CodewordBit, q.size),
+		modules:          make([][][8]image.Point, len(numDataCodewords)),
+	}
+	for y := range m.bits {
+		m.bits[y] = make([]CodewordBit, q.size)
+		for x := range m.bits[y] {
+			m.bits[y][x].Block = -1
+		}
+	}
+	for _, cw := range order {
+		m.modules[cw.Block] = append(m.modules[cw.Block], [8]image.Point{})
+	}
+
+	layout.forEachDataModule(func(x, y, i int) {
+		// Remainder bits after the last codeword carry no codeword.
+		if i>>3 >= len(order) {
+			return
+		}
+		cw := order[i>>3]
+		cw.Bit = 7 - (i & 7)
+		m.bits[y][x] = cw
+		m.modules[cw.Block][cw.Index][i&7] = image.Point{X: x, Y: y}
+	})
+	return m
+}
+
+// At returns the codeword bit carried by the module at the given coordinates.
+// It returns false for function modules, remainder bits and coordinates outside the QR code.
+func (m *CodewordMap) At(x, y int) (CodewordBit, bool) {
+	if y < 0 || y >= len(m.bits) || x < 0 || x >= len(m.bits) {
+		return CodewordBit{Block: -1}, false
+	}
+	cw := m.bits[y][x]
+	return cw, cw.Block != -1
+}
+
+// Modules returns the coordinates of the eight modules of codeword index in the given block,
+// ordered from the most significant bit (bit 7) to the least significant bit (bit 0).
+func (m *CodewordMap) Modules(block, index int) ([8]image.Point, error) {
+	if block < 0 || block >= len(m.modules) || index < 0 || index >= len(m.modules[block]) {
+		return [8]image.Point{}, fmt.Errorf("%w: no codeword %d in block %d", ErrInvalidArgument, index, block)
+	}
+	return m.modules[block][index], nil
+}
+
+// NumBlocks returns the number of error correction blocks.
+func (m *CodewordMap) NumBlocks() int {
+	return len(m.modules)
+}
+
+// NumCodewords returns the number of codewords, data and error correction, in the given block.
+func (m *CodewordMap) NumCodewords(block int) int {
+	if block < 0 || block >= len(m.modules) {
+		return 0
+	}
+	return len(m.modules[block])
+}
+
+// NumDataCodewords returns the number of data codewords in the given block.
+// The codewords with a higher index in the block are error correction codewords.
+func (m *CodewordMap) NumDataCodewords(block int) int {
+	if block < 0 || block >= len(m.numDataCodewords) {
+		return 0
+	}
+	return m.numDataCodewords[block]
+}
+
+// codewordOrder returns the block and index of each codeword in the interleaved order in which
+// addEccAndInterLeave emits them, and the number of data codewords of each block.
+func codewordOrder(ver int, ecl Ecc) ([]CodewordBit, []int) {
+	numBlocks := int(getNumErrorCorrectionBlocks()[ecl][ver])
+	blockEccLen := int(getEccCodeWordsPerBlock()[ecl][ver])
+	rawCodewords := getNumRawDataModules(ver) / 8
+
+	// Short blocks have one data codeword less than the long blocks following them.
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+	shortDataLen := shortBlockLen - blockEccLen
+
+	numDataCodewords := make([]int, numBlocks)
+	for j := range numDataCodewords {
+		numDataCodewords[j] = shortDataLen
+		if j >= numShortBlocks {
+			numDataCodewords[j]++
+		}
+	}
+
+	order := make([]CodewordBit, 0, rawCodewords)
+	for i := 0; i <= shortBlockLen; i++ {
+		for j := 0; j < numBlocks; j++ {
+			if i == shortDataLen && j < numShortBlocks {
+				continue
+			}
+			// Short blocks skip position shortDataLen, so their codewords after it are shifted by one.
+			index := i
+			if j < numShortBlocks && i > shortDataLen {
+				index--
+			}
+			order = append(order, CodewordBit{Block: j, Index: index})
+		}
+	}
+	return order, numDataCodewords
+}
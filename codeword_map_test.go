@@ -0,0 +1,107 @@
+package go_qr
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQrCode_CodewordMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		ecl     Ecc
+		mask    int
+	}{
+		{name: "version 1, single block", version: 1, ecl: Low, mask: 0},
+		{name: "version 5, short and long blocks", version: 5, ecl: Quartile, mask: 3},
+		{name: "version 7, remainder free", version: 7, ecl: High, mask: 5},
+		{name: "version 22, many blocks", version: 22, ecl: Medium, mask: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]byte, getNumDataCodewords(tt.version, tt.ecl))
+			for i := range data {
+				data[i] = byte(i*7 + 3)
+			}
+			qr, err := newQrCode(tt.version, tt.ecl, data, tt.mask)
+			if err != nil {
+				t.Fatalf("newQrCode() error = %v", err)
+			}
+
+			// Undo the mask to read back the codeword bits.
+			unmasked := qr.functionLayout()
+			for y := range unmasked.modules {
+				copy(unmasked.modules[y], qr.modules[y])
+			}
+			if err = unmasked.applyMask(qr.mask); err != nil {
+				t.Fatalf("applyMask() error = %v", err)
+			}
+
+			m := qr.CodewordMap()
+			blockEccLen := int(getEccCodeWordsPerBlock()[tt.ecl][tt.version])
+			rsDiv, err := reedSolomonComputeDivisor(blockEccLen)
+			if err != nil {
+				t.Fatalf("reedSolomonComputeDivisor() error = %v", err)
+			}
+
+			assert.Equal(t, int(getNumErrorCorrectionBlocks()[tt.ecl][tt.version]), m.NumBlocks())
+			offset := 0
+			for block := 0; block < m.NumBlocks(); block++ {
+				numData := m.NumDataCodewords(block)
+				assert.Equal(t, numData+blockEccLen, m.NumCodewords(block))
+
+				blockData := data[offset : offset+numData]
+				want := append(append([]byte{}, blockData...), reedSolomonComputeRemainder(blockData, rsDiv)...)
+				offset += numData
+
+				for index := 0; index < m.NumCodewords(block); index++ {
+					modules, err := m.Modules(block, index)
+					if err != nil {
+						t.Fatalf("Modules() error = %v", err)
+					}
+
+					got := 0
+					for i, p := range modules {
+						cw, ok := m.At(p.X, p.Y)
+						assert.True(t, ok)
+						assert.Equal(t, CodewordBit{Block: block, Index: index, Bit: 7 - i}, cw)
+						if unmasked.modules[p.Y][p.X] {
+							got |= 1 << (7 - i)
+						}
+					}
+					assert.Equal(t, int(want[index]), got, "codeword %d of block %d", index, block)
+				}
+			}
+			assert.Equal(t, len(data), offset)
+		})
+	}
+}
+
+func TestCodewordMap_OutOfRange(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	m := qr.CodewordMap()
+
+	_, ok := m.At(0, 0)
+	assert.False(t, ok, "finder module carries no codeword")
+	_, ok = m.At(-1, 5)
+	assert.False(t, ok)
+	_, ok = m.At(21, 5)
+	assert.False(t, ok)
+
+	_, err = m.Modules(1, 0)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+	_, err = m.Modules(0, 26)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	modules, err := m.Modules(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Point{X: 20, Y: 20}, modules[0])
+	assert.Equal(t, 0, m.NumCodewords(-1))
+	assert.Equal(t, 0, m.NumDataCodewords(1))
+}
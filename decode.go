@@ -0,0 +1,131 @@
+package go_qr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dataCodewords reads the data codewords back from the modules of the QR code,
+// undoing the mask and the block interleaving. Error correction codewords are not read.
+func (q *QrCode) dataCodewords() []byte {
+	layout := q.functionLayout()
+	order, numDataCodewords := codewordOrder(q.version, q.errorCorrectionLevel)
+
+	blocks := make([][]byte, len(numDataCodewords))
+	for j := range blocks {
+		blocks[j] = make([]byte, numDataCodewords[j])
+	}
+	layout.forEachDataModule(func(x, y, i int) {
+		if i>>3 >= len(order) {
+			return
+		}
+		cw := order[i>>3]
+		if cw.Index < numDataCodewords[cw.Block] && q.modules[y][x] != maskInverts(q.mask, x, y) {
+			blocks[cw.Block][cw.Index] |= 0x80 >> uint(i&7)
+		}
+	})
+
+	var data []byte
+	for _, block := range blocks {
+		data = append(data, block...)
+	}
+	return data
+}
+
+// decodeSegments parses the segments from the data codewords of the QR code,
+// up to the terminator or the end of the data.
+func (q *QrCode) decodeSegments() ([]*QrSegment, error) {
+	bb := &BitBuffer{}
+	for _, b := range q.dataCodewords() {
+		if err := bb.AppendBits(int(b), 8); err != nil {
+			return nil, err
+		}
+	}
+
+	r := NewBitReader(bb)
+	var segs []*QrSegment
+	for r.Remaining() >= 4 {
+		modeBits, _ := r.ReadBits(4)
+		if modeBits == 0 {
+			break // Terminator
+		}
+
+		var mode Mode
+		for _, m := range []Mode{Numeric, Alphanumeric, Byte, Kanji, Eci} {
+			if m.modeBits == modeBits {
+				mode = m
+			}
+		}
+		if mode.modeBits == 0 {
+			return nil, fmt.Errorf("%w: unknown mode indicator %#x", ErrInvalidArgument, modeBits)
+		}
+
+		numChars, numBits := 0, 8
+		if mode.isEci() {
+			// The number of leading one bits gives the length of the assignment value.
+			prefix := 0
+			for ; prefix < 3 && r.buf.GetBit(r.pos+prefix); prefix++ {
+			}
+			numBits = 8 * (prefix + 1)
+		} else {
+			var err error
+			numChars, err = r.ReadBits(mode.numCharCountBits(q.version))
+			if err != nil {
+				return nil, err
+			}
+			numBits = segmentDataBits(mode, numChars)
+		}
+
+		data := &BitBuffer{}
+		for ; numBits > 0; numBits -= min(numBits, 24) {
+			val, err := r.ReadBits(min(numBits, 24))
+			if err != nil {
+				return nil, err
+			}
+			if err := data.AppendBits(val, min(numBits, 24)); err != nil {
+				return nil, err
+			}
+		}
+
+		seg, err := newQrSegment(mode, numChars, data)
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
+
+// segmentDataBits returns the number of data bits of a segment of numChars characters in the given mode,
+// which is not the Eci mode.
+func segmentDataBits(mode Mode, numChars int) int {
+	switch {
+	case mode.isNumeric():
+		return numChars/3*10 + [...]int{0, 4, 7}[numChars%3]
+	case mode.isAlphanumeric():
+		return numChars/2*11 + numChars%2*6
+	case mode.isKanji():
+		return numChars * 13
+	default:
+		return numChars * 8
+	}
+}
+
+// decodeText returns the text encoded in the QR code. Byte segments are taken as UTF-8,
+// and ECI designators are skipped.
+func (q *QrCode) decodeText() (string, error) {
+	segs, err := q.decodeSegments()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, seg := range segs {
+		payload, _, err := segmentPayload(seg)
+		if err != nil {
+			return "", err
+		}
+		sb.Write(payload)
+	}
+	return sb.String(), nil
+}
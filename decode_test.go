@@ -0,0 +1,71 @@
+package go_qr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQrCode_decodeSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		desc string
+		ecl  Ecc
+	}{
+		{"all modes", "ECI:26 | N:0123456789 | A:HELLO WORLD | B:hex:DEADBEEF | K:点茗", Medium},
+		{"large ECI", "ECI:20000 | ECI:999999 | N:1", Low},
+		{"many blocks", "B:" + strings.Repeat("go-qr ", 200), High},
+		{"odd lengths", "N:12345 | A:ABC | N:1", Quartile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segs, err := ParseSegments(tt.desc)
+			if err != nil {
+				t.Fatalf("ParseSegments() error = %v", err)
+			}
+			for msk := 0; msk < 8; msk++ {
+				qr, err := EncodeSegments(segs, tt.ecl, MinVersion, MaxVersion, msk, false)
+				if err != nil {
+					t.Fatalf("EncodeSegments() error = %v", err)
+				}
+
+				got, err := qr.decodeSegments()
+				assert.NoError(t, err)
+				assert.Equal(t, segs, got, "mask %d", msk)
+			}
+		})
+	}
+}
+
+func TestQrCode_decodeText(t *testing.T) {
+	for _, text := range []string{"", "Hello, world!", "314159265358979323846264338327950288419716939937510", "こんにちwa、世界！ αβγδ"} {
+		qr, err := EncodeText(text, Low)
+		if err != nil {
+			t.Fatalf("EncodeText() error = %v", err)
+		}
+		got, err := qr.decodeText()
+		assert.NoError(t, err)
+		assert.Equal(t, text, got)
+	}
+}
+
+// undecodableQrCode returns a QR code of a raw segment whose payload cannot be decoded as text.
+func undecodableQrCode(t *testing.T) *QrCode {
+	// The 11 bits of two alphanumeric characters hold 2047, above the largest pair 44*45+44.
+	bits := &BitBuffer{}
+	if err := bits.AppendBits(0x7FF, 11); err != nil {
+		t.Fatalf("AppendBits() error = %v", err)
+	}
+	seg, err := NewRawSegment(Alphanumeric, 2, bits)
+	if err != nil {
+		t.Fatalf("NewRawSegment() error = %v", err)
+	}
+	qr, err := EncodeSegments([]*QrSegment{seg}, Low, MinVersion, MaxVersion, -1, false)
+	if err != nil {
+		t.Fatalf("EncodeSegments() error = %v", err)
+	}
+	_, err = qr.decodeText()
+	assert.Error(t, err)
+	return qr
+}
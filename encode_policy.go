@@ -0,0 +1,165 @@
+package go_qr
+
+import (
+	"fmt"
+)
+
+// EncodePolicy decides which version and error correction level are used to encode
+// the given segments. ecl is the minimum error correction level requested by the caller,
+// and minVer, maxVer bound the versions that may be chosen.
+// A policy must never return an error correction level lower than ecl, and must return
+// a version and level for which the segments fit.
+type EncodePolicy func(segs []*QrSegment, ecl Ecc, minVer, maxVer int) (version int, newEcl Ecc, err error)
+
+// SmallestVersion is an EncodePolicy that uses the smallest version in which the data fits
+// and keeps the requested error correction level unchanged.
+func SmallestVersion(segs []*QrSegment, ecl Ecc, minVer, maxVer int) (int, Ecc, error) {
+	version, _, err := findSmallestVersion(segs, ecl, minVer, maxVer)
+	if err != nil {
+		return 0, ecl, err
+	}
+	return version, ecl, nil
+}
+
+// BoostEcl is an EncodePolicy that uses the smallest version in which the data fits,
+// then raises the error correction level as far as the data still fits in that version.
+// This is the behaviour of EncodeSegments with boostEcl set to true.
+func BoostEcl(segs []*QrSegment, ecl Ecc, minVer, maxVer int) (int, Ecc, error) {
+	return BoostEclUpTo(High)(segs, ecl, minVer, maxVer)
+}
+
+// BoostEclUpTo returns an EncodePolicy that behaves like BoostEcl, but never raises
+// the error correction level above limit.
+func BoostEclUpTo(limit Ecc) EncodePolicy {
+	return func(segs []*QrSegment, ecl Ecc, minVer, maxVer int) (int, Ecc, error) {
+		version, dataUsedBits, err := findSmallestVersion(segs, ecl, minVer, maxVer)
+		if err != nil {
+			return 0, ecl, err
+		}
+		return version, boostEclWithin(version, dataUsedBits, ecl, limit), nil
+	}
+}
+
+// PreferEcl returns an EncodePolicy that accepts up to extraVersions versions more than
+// the smallest one in which the data fits, if that allows the data to be encoded
+// with the target error correction level. For example, PreferEcl(High, 1) prefers
+// a code one version larger when that reaches High.
+// If target cannot be reached within those versions, it behaves like BoostEcl.
+func PreferEcl(target Ecc, extraVersions int) EncodePolicy {
+	return func(segs []*QrSegment, ecl Ecc, minVer, maxVer int) (int, Ecc, error) {
+		version, dataUsedBits, err := findSmallestVersion(segs, ecl, minVer, maxVer)
+		if err != nil {
+			return 0, ecl, err
+		}
+
+		if target > ecl {
+			for ver := version; ver <= min(version+extraVersions, maxVer); ver++ {
+				usedBits := getTotalBits(segs, ver)
+				if usedBits != -1 && usedBits <= getNumDataCodewords(ver, target)*8 {
+					return ver, boostEclWithin(ver, usedBits, target, High), nil
+				}
+			}
+		}
+		return version, boostEclWithin(version, dataUsedBits, ecl, High), nil
+	}
+}
+
+// FixedSize returns an EncodePolicy that always produces a code of size modules per side
+// (without quiet zone), and uses the highest error correction level that fits in it.
+// size must be a valid QR Code size, that is 4*version+17 for a version within minVer and maxVer.
+func FixedSize(size int) EncodePolicy {
+	return func(segs []*QrSegment, ecl Ecc, minVer, maxVer int) (int, Ecc, error) {
+		version := (size - 17) / 4
+		if size < 21 || (size-17)%4 != 0 || version < minVer || version > maxVer {
+			return 0, ecl, &ConfigError{Field: "size", Msg: fmt.Sprintf("size %d does not match a version between %d and %d", size, minVer, maxVer)}
+		}
+
+		dataUsedBits, err := checkFits(segs, version, ecl)
+		if err != nil {
+			return 0, ecl, err
+		}
+		return version, boostEclWithin(version, dataUsedBits, ecl, High), nil
+	}
+}
+
+// FitPrintWidth returns an EncodePolicy for codes printed at a fixed width of widthPx pixels,
+// including a quiet zone of quietZone modules on each side.
+// It chooses the version that gives the largest integer number of pixels per module,
+// and among the versions that give the same module size, the one reaching the highest
+// error correction level.
+func FitPrintWidth(widthPx, quietZone int) EncodePolicy {
+	return func(segs []*QrSegment, ecl Ecc, minVer, maxVer int) (int, Ecc, error) {
+		if quietZone < 0 {
+			return 0, ecl, &ConfigError{Field: "quietZone", Msg: "quiet zone must be non-negative"}
+		}
+
+		version, dataUsedBits, err := findSmallestVersion(segs, ecl, minVer, maxVer)
+		if err != nil {
+			return 0, ecl, err
+		}
+
+		scale := widthPx / (version*4 + 17 + quietZone*2)
+		if scale < 1 {
+			return 0, ecl, &ConfigError{Field: "widthPx", Msg: fmt.Sprintf("print width %d px is too small for version %d", widthPx, version)}
+		}
+
+		bestVer, bestEcl := version, boostEclWithin(version, dataUsedBits, ecl, High)
+		for ver := version + 1; ver <= maxVer && bestEcl < High; ver++ {
+			if widthPx/(ver*4+17+quietZone*2) != scale {
+				break
+			}
+			usedBits, err := checkFits(segs, ver, ecl)
+			if err != nil {
+				continue
+			}
+			if newEcl := boostEclWithin(ver, usedBits, ecl, High); newEcl > bestEcl {
+				bestVer, bestEcl = ver, newEcl
+			}
+		}
+		return bestVer, bestEcl, nil
+	}
+}
+
+// findSmallestVersion finds the smallest version between minVer and maxVer in which the segments fit
+// at the given error correction level. It returns the version and the number of data bits used.
+func findSmallestVersion(segs []*QrSegment, ecl Ecc, minVer, maxVer int) (int, int, error) {
+	for version := minVer; ; version++ {
+		// Calculate data capacity bits
+		dataCapacityBits := getNumDataCodewords(version, ecl) * 8
+		// Count total bits used
+		dataUsedBits := getTotalBits(segs, version)
+		if dataUsedBits != -1 && dataUsedBits <= dataCapacityBits {
+			return version, dataUsedBits, nil
+		}
+
+		// If no suitable version found then throw a Segment too long error
+		if version >= maxVer {
+			return 0, 0, newDataTooLongException(dataUsedBits, dataCapacityBits, version, ecl)
+		}
+	}
+}
+
+// checkFits checks whether the segments fit in the given version at the given error correction level,
+// and returns the number of data bits used.
+func checkFits(segs []*QrSegment, version int, ecl Ecc) (int, error) {
+	dataCapacityBits := getNumDataCodewords(version, ecl) * 8
+	dataUsedBits := getTotalBits(segs, version)
+	if dataUsedBits == -1 || dataUsedBits > dataCapacityBits {
+		return 0, newDataTooLongException(dataUsedBits, dataCapacityBits, version, ecl)
+	}
+	return dataUsedBits, nil
+}
+
+// boostEclWithin returns the highest error correction level between ecl and limit
+// at which dataUsedBits still fit in the given version.
+func boostEclWithin(version, dataUsedBits int, ecl, limit Ecc) Ecc {
+	if dataUsedBits == -1 {
+		return ecl
+	}
+	for _, newEcl := range []Ecc{Medium, Quartile, High} {
+		if newEcl > ecl && newEcl <= limit && dataUsedBits <= getNumDataCodewords(version, newEcl)*8 {
+			ecl = newEcl
+		}
+	}
+	return ecl
+}
@@ -0,0 +1,155 @@
+package go_qr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeSegmentsWithPolicy(t *testing.T) {
+	// "Hello, world!" in byte mode uses 116 bits, which fits version 1 up to Medium
+	// and version 2 up to High.
+	tests := []struct {
+		name        string
+		policy      EncodePolicy
+		ecl         Ecc
+		maxVersion  int
+		wantErr     bool
+		wantVersion int
+		wantEcl     Ecc
+	}{
+		{
+			name:        "smallest version keeps ecl",
+			policy:      SmallestVersion,
+			ecl:         Low,
+			maxVersion:  MaxVersion,
+			wantVersion: 1,
+			wantEcl:     Low,
+		},
+		{
+			name:        "boost within smallest version",
+			policy:      BoostEcl,
+			ecl:         Low,
+			maxVersion:  MaxVersion,
+			wantVersion: 1,
+			wantEcl:     Medium,
+		},
+		{
+			name:        "boost capped at Low",
+			policy:      BoostEclUpTo(Low),
+			ecl:         Low,
+			maxVersion:  MaxVersion,
+			wantVersion: 1,
+			wantEcl:     Low,
+		},
+		{
+			name:        "prefer one version larger for High",
+			policy:      PreferEcl(High, 1),
+			ecl:         Low,
+			maxVersion:  MaxVersion,
+			wantVersion: 2,
+			wantEcl:     High,
+		},
+		{
+			name:        "prefer High not reachable falls back to boost",
+			policy:      PreferEcl(High, 0),
+			ecl:         Low,
+			maxVersion:  MaxVersion,
+			wantVersion: 1,
+			wantEcl:     Medium,
+		},
+		{
+			name:        "prefer High limited by max version",
+			policy:      PreferEcl(High, 3),
+			ecl:         Low,
+			maxVersion:  1,
+			wantVersion: 1,
+			wantEcl:     Medium,
+		},
+		{
+			name:        "fixed size",
+			policy:      FixedSize(25),
+			ecl:         Low,
+			maxVersion:  MaxVersion,
+			wantVersion: 2,
+			wantEcl:     High,
+		},
+		{
+			name:       "fixed size not a valid size",
+			policy:     FixedSize(22),
+			ecl:        Low,
+			maxVersion: MaxVersion,
+			wantErr:    true,
+		},
+		{
+			name:       "fixed size too small for the data",
+			policy:     FixedSize(21),
+			ecl:        Quartile,
+			maxVersion: MaxVersion,
+			wantErr:    true,
+		},
+		{
+			name:        "print width where a larger version has the same module size",
+			policy:      FitPrintWidth(66, 0),
+			ecl:         Low,
+			maxVersion:  MaxVersion,
+			wantVersion: 1,
+			wantEcl:     Medium,
+		},
+		{
+			name:        "print width with quiet zone",
+			policy:      FitPrintWidth(66, 4),
+			ecl:         Low,
+			maxVersion:  MaxVersion,
+			wantVersion: 2,
+			wantEcl:     High,
+		},
+		{
+			name:       "print width too small",
+			policy:     FitPrintWidth(20, 4),
+			ecl:        Low,
+			maxVersion: MaxVersion,
+			wantErr:    true,
+		},
+		{
+			name:       "nil policy",
+			policy:     nil,
+			ecl:        Low,
+			maxVersion: MaxVersion,
+			wantErr:    true,
+		},
+	}
+
+	segs, err := MakeSegments("Hello, world!")
+	if err != nil {
+		t.Fatalf("MakeSegments() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeSegmentsWithPolicy(segs, tt.ecl, MinVersion, tt.maxVersion, -1, tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EncodeSegmentsWithPolicy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			assert.Equal(t, tt.wantVersion, got.version)
+			assert.Equal(t, tt.wantEcl, got.errorCorrectionLevel)
+		})
+	}
+}
+
+func TestEncodeSegmentsWithPolicy_DataTooLong(t *testing.T) {
+	segs, err := MakeSegments("Hello, world! Hello, world! Hello, world!")
+	if err != nil {
+		t.Fatalf("MakeSegments() error = %v", err)
+	}
+
+	_, err = EncodeSegmentsWithPolicy(segs, High, MinVersion, 1, -1, BoostEcl)
+	var dataTooLong *DataTooLongException
+	assert.True(t, errors.As(err, &dataTooLong))
+}
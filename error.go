@@ -1,9 +1,126 @@
 package go_qr
 
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned by the package. They can be matched with errors.Is,
+// also when they are wrapped or returned as one of the structured error types below.
+var (
+	// ErrDataTooLong is matched by errors which are raised when the data does not fit in the allowed versions.
+	ErrDataTooLong = errors.New("data too long")
+	// ErrInvalidVersion is returned when a version or version range is outside MinVersion and MaxVersion.
+	ErrInvalidVersion = errors.New("invalid version")
+	// ErrInvalidMask is returned when a mask value is not between -1 and 7.
+	ErrInvalidMask = errors.New("mask value out of range")
+	// ErrInvalidCharacter is matched by errors which are raised when a character cannot be encoded.
+	ErrInvalidCharacter = errors.New("invalid character")
+	// ErrInvalidConfig is matched by errors which are raised for an invalid configuration.
+	ErrInvalidConfig = errors.New("invalid configuration")
+	// ErrInvalidArgument is returned for any other invalid argument.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrQuietZoneTooSmall is matched by warnings about a quiet zone narrower than MinQuietZone modules.
+	ErrQuietZoneTooSmall = errors.New("quiet zone too small")
+	// ErrLowContrast is matched by warnings about colors with a contrast below MinContrast against the light color.
+	ErrLowContrast = errors.New("contrast too low")
+	// ErrLogoTooLarge is matched by errors which are raised when a logo covers more of a QR code than it can lose.
+	ErrLogoTooLarge = errors.New("logo too large")
+)
+
+// DataTooLongException is returned when the data does not fit in a QR code
+// with the requested version range and error correction level.
 type DataTooLongException struct {
 	Msg string
+
+	UsedBits     int // Number of data bits needed by the segments, or -1 if a segment is too long for any version.
+	CapacityBits int // Number of data bits available in Version at Ecl.
+	Version      int // Largest version that was tried, or 0 if unknown.
+	Ecl          Ecc // Error correction level that was requested.
+}
+
+// newDataTooLongException creates a DataTooLongException for data which needs usedBits,
+// while only capacityBits are available at the given version and error correction level.
+func newDataTooLongException(usedBits, capacityBits, version int, ecl Ecc) *DataTooLongException {
+	msg := "Segment too long"
+	if usedBits != -1 {
+		msg = fmt.Sprintf("Data length = %d bits, Max capacity = %d bits", usedBits, capacityBits)
+	}
+	return &DataTooLongException{
+		Msg:          msg,
+		UsedBits:     usedBits,
+		CapacityBits: capacityBits,
+		Version:      version,
+		Ecl:          ecl,
+	}
 }
 
 func (d *DataTooLongException) Error() string {
 	return d.Msg
 }
+
+// Is reports whether target is ErrDataTooLong.
+func (d *DataTooLongException) Is(target error) bool {
+	return target == ErrDataTooLong
+}
+
+// InvalidCharacterError is returned when a character cannot be encoded.
+type InvalidCharacterError struct {
+	Char     rune // The character which cannot be encoded.
+	Position int  // Byte offset of the character in the input text.
+	Mode     Mode // The mode in which the character cannot be encoded, or the zero Mode if it cannot be encoded at all.
+}
+
+func (e *InvalidCharacterError) Error() string {
+	if e.Mode.modeBits == 0 {
+		return fmt.Sprintf("invalid character %q at position %d", e.Char, e.Position)
+	}
+	return fmt.Sprintf("character %q at position %d cannot be encoded in %s mode", e.Char, e.Position, e.Mode)
+}
+
+// Is reports whether target is ErrInvalidCharacter.
+func (e *InvalidCharacterError) Is(target error) bool {
+	return target == ErrInvalidCharacter
+}
+
+// ConfigError is returned when a configuration value is invalid.
+type ConfigError struct {
+	Field string // Name of the invalid configuration value, for example "scale".
+	Msg   string
+	Err   error // Underlying error, or nil.
+}
+
+func (e *ConfigError) Error() string {
+	return e.Msg
+}
+
+// Unwrap returns the underlying error.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidConfig.
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// SegmentSyntaxError is returned by ParseSegments for an invalid segment description.
+type SegmentSyntaxError struct {
+	Offset int    // Byte offset of the problem in the description.
+	Msg    string // Description of the problem.
+	Err    error  // Underlying error, for example an InvalidCharacterError, or nil.
+}
+
+func (e *SegmentSyntaxError) Error() string {
+	return fmt.Sprintf("segment syntax error at offset %d: %s", e.Offset, e.Msg)
+}
+
+// Unwrap returns the underlying error.
+func (e *SegmentSyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInvalidArgument.
+func (e *SegmentSyntaxError) Is(target error) bool {
+	return target == ErrInvalidArgument
+}
@@ -1,6 +1,12 @@
 package go_qr
 
-import "testing"
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
 
 func TestDataTooLongException_Error(t *testing.T) {
 	testCases := []struct {
@@ -30,3 +36,99 @@ func TestDataTooLongException_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestDataTooLongException_Fields(t *testing.T) {
+	segs, err := MakeSegments(strings.Repeat("Hello, world! ", 5))
+	if err != nil {
+		t.Fatalf("MakeSegments() error = %v", err)
+	}
+
+	_, err = EncodeSegments(segs, Medium, 1, 2, -1, true)
+	assert.ErrorIs(t, err, ErrDataTooLong)
+
+	var dataTooLong *DataTooLongException
+	if assert.ErrorAs(t, err, &dataTooLong) {
+		assert.Equal(t, 4+8+70*8, dataTooLong.UsedBits)
+		assert.Equal(t, 28*8, dataTooLong.CapacityBits)
+		assert.Equal(t, 2, dataTooLong.Version)
+		assert.Equal(t, Medium, dataTooLong.Ecl)
+		assert.Equal(t, "Data length = 572 bits, Max capacity = 224 bits", dataTooLong.Error())
+	}
+}
+
+func TestInvalidCharacterError(t *testing.T) {
+	tests := []struct {
+		name         string
+		make         func() (*QrSegment, error)
+		wantChar     rune
+		wantPosition int
+		wantMode     Mode
+		wantMsg      string
+	}{
+		{
+			name:         "numeric",
+			make:         func() (*QrSegment, error) { return MakeNumeric("12a4") },
+			wantChar:     'a',
+			wantPosition: 2,
+			wantMode:     Numeric,
+			wantMsg:      "character 'a' at position 2 cannot be encoded in numeric mode",
+		},
+		{
+			name:         "alphanumeric",
+			make:         func() (*QrSegment, error) { return MakeAlphanumeric("ABc") },
+			wantChar:     'c',
+			wantPosition: 2,
+			wantMode:     Alphanumeric,
+			wantMsg:      "character 'c' at position 2 cannot be encoded in alphanumeric mode",
+		},
+		{
+			name:         "kanji",
+			make:         func() (*QrSegment, error) { return MakeKanji("魔法a") },
+			wantChar:     'a',
+			wantPosition: 6,
+			wantMode:     Kanji,
+			wantMsg:      "character 'a' at position 6 cannot be encoded in kanji mode",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.make()
+			assert.ErrorIs(t, err, ErrInvalidCharacter)
+
+			var invalidChar *InvalidCharacterError
+			if assert.ErrorAs(t, err, &invalidChar) {
+				assert.Equal(t, tt.wantChar, invalidChar.Char)
+				assert.Equal(t, tt.wantPosition, invalidChar.Position)
+				assert.Equal(t, tt.wantMode, invalidChar.Mode)
+				assert.Equal(t, tt.wantMsg, invalidChar.Error())
+			}
+		})
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	err = qr.WriteAsPNG(NewQrCodeImgConfig(0, 4), &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	var configErr *ConfigError
+	if assert.ErrorAs(t, err, &configErr) {
+		assert.Equal(t, "scale", configErr.Field)
+	}
+
+	_, err = EncodeSegments([]*QrSegment{}, Low, 2, 1, -1, true)
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+
+	_, err = EncodeSegments([]*QrSegment{}, Low, 1, 1, 8, true)
+	assert.ErrorIs(t, err, ErrInvalidMask)
+
+	_, err = MakeBytes(nil)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+
+	_, err = MakeEci(-1)
+	assert.ErrorIs(t, err, ErrInvalidArgument)
+}
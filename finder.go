@@ -0,0 +1,265 @@
+package go_qr
+
+import (
+	"image/color"
+	"strconv"
+)
+
+// FinderCorner is the corner of one of the three finder patterns of a QR code.
+type FinderCorner int
+
+const (
+	FinderTopLeft    FinderCorner = iota // Finder pattern in the top left corner
+	FinderTopRight                       // Finder pattern in the top right corner
+	FinderBottomLeft                     // Finder pattern in the bottom left corner
+)
+
+const (
+	finderCount = 3 // Number of finder patterns
+	finderSize  = 7 // Width of a finder pattern in modules
+)
+
+// EyeShape is the shape of the outer ring or of the inner eye of a finder pattern.
+type EyeShape int
+
+const (
+	EyeSquare  EyeShape = iota // Square, as in the standard
+	EyeRounded                 // Square with rounded corners
+	EyeCircle                  // Circle, or circular ring
+	EyeLeaf                    // Square with large rounded corners, except for the corner facing the center of the QR code
+)
+
+// eyeShapeNames maps the EyeShape to its name.
+var eyeShapeNames = [...]string{"square", "rounded", "circle", "leaf"}
+
+// String returns the name of the shape, for example "leaf".
+func (s EyeShape) String() string {
+	if s < EyeSquare || s > EyeLeaf {
+		return "EyeShape(" + strconv.Itoa(int(s)) + ")"
+	}
+	return eyeShapeNames[s]
+}
+
+// FinderStyle is the style in which a finder pattern is drawn: the 7x7 outer ring, and the 3x3 eye inside it.
+type FinderStyle struct {
+	RingShape EyeShape
+	RingColor color.Color // Color of the ring, or nil for the dark color.
+	EyeShape  EyeShape
+	EyeColor  color.Color // Color of the eye, or nil for the dark color.
+}
+
+// WithFinderStyle returns a function that sets the style of the finder patterns in the given corners,
+// or of all three if no corner is given, in PNG and SVG output in the provided QrCodeImgConfig.
+// A finder pattern with a style is drawn whole in it, replacing the module shape.
+func WithFinderStyle(style FinderStyle, corners ...FinderCorner) func(*QrCodeImgConfig) {
+	if len(corners) == 0 {
+		corners = []FinderCorner{FinderTopLeft, FinderTopRight, FinderBottomLeft}
+	} else {
+		corners = append([]FinderCorner(nil), corners...)
+	}
+	return func(q *QrCodeImgConfig) {
+		for _, corner := range corners {
+			if corner < FinderTopLeft || corner > FinderBottomLeft {
+				// Kept to be reported by Valid.
+				q.options.invalidFinderCorner = true
+				continue
+			}
+			s := style
+			q.options.finderStyles[corner] = &s
+		}
+	}
+}
+
+// validFinderStyles checks the corners and shapes of the finder styles.
+func (q *QrCodeImgConfig) validFinderStyles() error {
+	if q.options.invalidFinderCorner {
+		return &ConfigError{Field: "finderStyles", Msg: "unknown finder pattern corner"}
+	}
+	for _, style := range q.options.finderStyles {
+		if style == nil {
+			continue
+		}
+		for _, shape := range []EyeShape{style.RingShape, style.EyeShape} {
+			if shape < EyeSquare || shape > EyeLeaf {
+				return &ConfigError{Field: "finderStyles", Msg: "unknown finder pattern shape " + shape.String()}
+			}
+		}
+	}
+	return nil
+}
+
+// finderColors returns the colors of the finder styles which replace the dark color.
+func (q *QrCodeImgConfig) finderColors() []color.Color {
+	var colors []color.Color
+	for _, style := range q.options.finderStyles {
+		if style == nil {
+			continue
+		}
+		for _, c := range []color.Color{style.RingColor, style.EyeColor} {
+			if c != nil {
+				colors = append(colors, c)
+			}
+		}
+	}
+	return colors
+}
+
+// finderPalette returns the palette of an image of a QR code drawn with QrCodeImgConfig: the light color,
+// the dark color and the distinct colors of the finder styles, and the palette indexes of the ring and eye
+// of each finder pattern.
+func (q *QrCodeImgConfig) finderPalette() (color.Palette, [finderCount][2]uint8) {
+	palette := color.Palette{q.Light(), q.Dark()}
+	indexOf := func(c color.Color) uint8 {
+		if c == nil {
+			return darkIndex
+		}
+		r, g, b, a := c.RGBA()
+		for i, p := range palette {
+			if pr, pg, pb, pa := p.RGBA(); pr == r && pg == g && pb == b && pa == a {
+				return uint8(i)
+			}
+		}
+		palette = append(palette, c)
+		return uint8(len(palette) - 1)
+	}
+
+	var indexes [finderCount][2]uint8
+	for corner, style := range q.options.finderStyles {
+		if style != nil {
+			indexes[corner] = [2]uint8{indexOf(style.RingColor), indexOf(style.EyeColor)}
+		}
+	}
+	return palette, indexes
+}
+
+// finderAt returns the corner of the finder pattern which contains the module at (x, y),
+// and the position of the module in the finder pattern.
+func (q *QrCode) finderAt(x, y int) (corner FinderCorner, fx, fy int, ok bool) {
+	switch {
+	case x < 0 || y < 0 || x >= q.size || y >= q.size:
+		return 0, 0, 0, false
+	case x < finderSize && y < finderSize:
+		return FinderTopLeft, x, y, true
+	case x >= q.size-finderSize && y < finderSize:
+		return FinderTopRight, x - (q.size - finderSize), y, true
+	case x < finderSize && y >= q.size-finderSize:
+		return FinderBottomLeft, x, y - (q.size - finderSize), true
+	}
+	return 0, 0, 0, false
+}
+
+// finderOrigin returns the position of the top left module of the finder pattern in the corner.
+func (q *QrCode) finderOrigin(corner FinderCorner) (x, y int) {
+	switch corner {
+	case FinderTopRight:
+		return q.size - finderSize, 0
+	case FinderBottomLeft:
+		return 0, q.size - finderSize
+	}
+	return 0, 0
+}
+
+// roundedBox is a rectangle with rounded corners. The radii are given clockwise from the top left corner.
+type roundedBox struct {
+	x, y, w, h float64
+	radii      [4]float64
+}
+
+// contains reports whether the point (px, py) is inside the box.
+func (b roundedBox) contains(px, py float64) bool {
+	if px < b.x || py < b.y || px > b.x+b.w || py > b.y+b.h {
+		return false
+	}
+	for i, r := range b.radii {
+		// The center of the arc lies inside the box, r from both sides of the corner.
+		left, top := i == 0 || i == 3, i == 0 || i == 1
+		cx, cy := b.x+b.w-r, b.y+b.h-r
+		if left {
+			cx = b.x + r
+		}
+		if top {
+			cy = b.y + r
+		}
+		inCorner := (left && px < cx || !left && px > cx) && (top && py < cy || !top && py > cy)
+		if r > 0 && inCorner && (px-cx)*(px-cx)+(py-cy)*(py-cy) > r*r {
+			return false
+		}
+	}
+	return true
+}
+
+// scaled returns the box scaled by s and moved by (dx, dy).
+func (b roundedBox) scaled(s, dx, dy float64) roundedBox {
+	scaled := roundedBox{x: b.x*s + dx, y: b.y*s + dy, w: b.w * s, h: b.h * s}
+	for i, r := range b.radii {
+		scaled.radii[i] = r * s
+	}
+	return scaled
+}
+
+// eyeBox returns the square box of the given size in modules with an offset from the top left of a finder
+// pattern in the corner, with its corners rounded by r. The leaf shape keeps the corner square which faces
+// the center of the QR code.
+func eyeBox(corner FinderCorner, shape EyeShape, offset, size, r float64) roundedBox {
+	b := roundedBox{x: offset, y: offset, w: size, h: size, radii: [4]float64{r, r, r, r}}
+	if shape == EyeLeaf {
+		switch corner {
+		case FinderTopLeft:
+			b.radii[2] = 0
+		case FinderTopRight:
+			b.radii[3] = 0
+		case FinderBottomLeft:
+			b.radii[1] = 0
+		}
+	}
+	return b
+}
+
+// finderRadii maps the EyeShape to the corner radii in modules of the outside of the ring,
+// the inside of the ring and the eye.
+var finderRadii = [...][3]float64{
+	EyeSquare:  {0, 0, 0},
+	EyeRounded: {2, 1, 1},
+	EyeCircle:  {3.5, 2.5, 1.5},
+	EyeLeaf:    {3, 2, 1.5},
+}
+
+// finderBoxes returns the outer and inner boundary of the ring and the eye of a finder pattern
+// in the corner drawn in the style, in modules from the top left of the finder pattern.
+func finderBoxes(corner FinderCorner, style *FinderStyle) (outer, inner, eye roundedBox) {
+	ring := finderRadii[style.RingShape]
+	return eyeBox(corner, style.RingShape, 0, 7, ring[0]),
+		eyeBox(corner, style.RingShape, 1, 5, ring[1]),
+		eyeBox(corner, style.EyeShape, 2, 3, finderRadii[style.EyeShape][2])
+}
+
+// writeFinderPaths writes the finder patterns which have a style of their own, with margins in pixels:
+// a path for the ring of each, filled by the even-odd rule, and one for its eye. The rings and eyes
+// without a color of their own are filled with the dark paint.
+func (s *moduleStyle) writeFinderPaths(sw *svgWriter, margins Margins, dark svgPaint) {
+	scale := float64(s.scale)
+	paint := func(c color.Color) svgPaint {
+		if c == nil {
+			return dark
+		}
+		return newSVGPaint(c)
+	}
+	for corner, style := range s.finders {
+		if style == nil {
+			continue
+		}
+		x, y := s.qr.finderOrigin(FinderCorner(corner))
+		dx, dy := float64(x*s.scale+margins.Left), float64(y*s.scale+margins.Top)
+		boxes := s.finderBoxes[corner]
+
+		sw.writeString("\t<path d=\"")
+		writeRoundedBox(sw, boxes[0].scaled(scale, dx, dy))
+		sw.writeString(" ")
+		writeRoundedBox(sw, boxes[1].scaled(scale, dx, dy))
+		sw.writeString("\" fill-rule=\"evenodd\"" + paint(style.RingColor).attrs() + "/>\n")
+
+		sw.writeString("\t<path d=\"")
+		writeRoundedBox(sw, boxes[2].scaled(scale, dx, dy))
+		sw.writeString("\"" + paint(style.EyeColor).attrs() + "/>\n")
+	}
+}
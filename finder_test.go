@@ -0,0 +1,206 @@
+package go_qr
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testRed  = color.RGBA{R: 0xFF, A: 0xFF}
+	testBlue = color.RGBA{B: 0xFF, A: 0xFF}
+)
+
+func TestEyeShape_String(t *testing.T) {
+	assert.Equal(t, "square", EyeSquare.String())
+	assert.Equal(t, "leaf", EyeLeaf.String())
+	assert.Equal(t, "EyeShape(4)", EyeShape(4).String())
+}
+
+func TestQrCodeImgConfig_validFinderStyles(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*QrCodeImgConfig)
+		wantErr bool
+	}{
+		{"all corners", []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{RingShape: EyeCircle, EyeShape: EyeLeaf})}, false},
+		{"one corner", []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{RingColor: testRed}, FinderBottomLeft)}, false},
+		{"unknown corner", []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{}, FinderCorner(3))}, true},
+		{"unknown ring shape", []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{RingShape: EyeShape(-1)})}, true},
+		{"unknown eye shape", []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{EyeShape: EyeShape(4)})}, true},
+		{"colored gray1", []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{EyeColor: testRed}), WithPNGColorMode(PNGColorGray1)}, true},
+		{"white gray1", []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{EyeColor: color.White}), WithPNGColorMode(PNGColorGray1)}, false},
+	}
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := qr.WriteAsPNG(NewQrCodeImgConfig(10, 4, tt.options...), &bytes.Buffer{})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalidConfig))
+			}
+		})
+	}
+}
+
+func TestQrCode_WriteAsPNG_FinderStyle(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	const scale = 10
+	end := qr.GetSize()*scale - 1
+
+	tests := []struct {
+		name  string
+		style FinderStyle
+		// Expected colors of the outer corner, the ring, the light module inside the ring,
+		// the eye's center and the ring's corner facing the center of the top left finder pattern.
+		corner, ring, inside, eye, centerCorner color.Color
+	}{
+		{"square", FinderStyle{RingColor: testRed, EyeColor: testBlue}, testRed, testRed, color.White, testBlue, testRed},
+		{"default colors", FinderStyle{RingShape: EyeRounded}, color.White, color.Black, color.White, color.Black, color.White},
+		{"circle", FinderStyle{RingShape: EyeCircle, EyeShape: EyeCircle, EyeColor: testRed}, color.White, color.Black, color.White, testRed, color.White},
+		{"leaf", FinderStyle{RingShape: EyeLeaf, EyeShape: EyeLeaf, RingColor: testBlue}, color.White, testBlue, color.White, color.Black, testBlue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewQrCodeImgConfig(scale, 0, WithFinderStyle(tt.style, FinderTopLeft))
+			var buf bytes.Buffer
+			assert.NoError(t, qr.WriteAsPNG(config, &buf))
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+
+			same := func(want color.Color, x, y int) {
+				t.Helper()
+				assert.Equal(t, color.RGBAModel.Convert(want), color.RGBAModel.Convert(img.At(x, y)), "pixel (%d, %d)", x, y)
+			}
+			same(tt.corner, 0, 0)
+			same(tt.ring, 35, 5)
+			same(tt.inside, 15, 35)
+			same(tt.eye, 35, 35)
+			same(tt.centerCorner, 69, 69)
+			// The other finder patterns are unchanged.
+			same(color.Black, end, 0)
+			same(color.Black, 0, end)
+
+			view, err := qr.Image(config)
+			assert.NoError(t, err)
+			for y := 0; y <= end; y++ {
+				for x := 0; x <= end; x++ {
+					if color.RGBAModel.Convert(img.At(x, y)) != color.RGBAModel.Convert(view.At(x, y)) {
+						t.Fatalf("pixel (%d, %d) of the PNG and the image differ", x, y)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestQrCode_WriteAsPNG_FinderStylePalette(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	config := NewQrCodeImgConfig(10, 4,
+		WithPNGColorMode(PNGColorPaletted),
+		WithFinderStyle(FinderStyle{RingColor: testRed, EyeColor: testBlue}),
+		WithFinderStyle(FinderStyle{RingColor: color.Black, EyeColor: testRed}, FinderBottomLeft))
+	var buf bytes.Buffer
+	assert.NoError(t, qr.WriteAsPNG(config, &buf))
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	palette := img.ColorModel().(color.Palette)
+	assert.Len(t, palette, 4)
+	assert.Equal(t, color.RGBAModel.Convert(testBlue), color.RGBAModel.Convert(img.At(75, 75)))
+}
+
+func TestQrCode_SVGString_FinderStyle(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		options     []func(*QrCodeImgConfig)
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:        "square",
+			options:     []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{RingColor: testRed}, FinderTopLeft)},
+			contains:    []string{`<path d="M0,0H70V70H0V0z M10,10H60V60H10V10z" fill-rule="evenodd" fill="#FF0000"/>`, `<path d="M20,20H50V50H20V20z" fill="#000000"/>`},
+			notContains: []string{"M0,0h10v10h-10z"},
+		},
+		{
+			name:     "circle eye",
+			options:  []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{EyeShape: EyeCircle}, FinderTopLeft)},
+			contains: []string{`<path d="M35,20A15,15 0 0 1 50,35A15,15 0 0 1 35,50A15,15 0 0 1 20,35A15,15 0 0 1 35,20z" fill="#000000"/>`},
+		},
+		{
+			name:     "leaf eye",
+			options:  []func(*QrCodeImgConfig){WithFinderStyle(FinderStyle{EyeShape: EyeLeaf}, FinderTopLeft)},
+			contains: []string{`<path d="M35,20A15,15 0 0 1 50,35V50H35A15,15 0 0 1 20,35A15,15 0 0 1 35,20z" fill="#000000"/>`},
+		},
+		{
+			name:     "classes",
+			options:  []func(*QrCodeImgConfig){WithSVGClasses("", "qr-dark"), WithFinderStyle(FinderStyle{EyeColor: testBlue})},
+			contains: []string{`fill-rule="evenodd" class="qr-dark"/>`, `" fill="#0000FF"/>`},
+		},
+		{
+			name:        "optimized",
+			options:     []func(*QrCodeImgConfig){WithOptimalSVG(), WithFinderStyle(FinderStyle{RingShape: EyeRounded})},
+			contains:    []string{`<path d="M20,0H50A20,20 0 0 1 70,20V50A20,20 0 0 1 50,70H20A20,20 0 0 1 0,50V20A20,20 0 0 1 20,0z M20,10H50A10,10 0 0 1 60,20V50A10,10 0 0 1 50,60H20A10,10 0 0 1 10,50V20A10,10 0 0 1 20,10z" fill-rule="evenodd" fill="#000000"/>`},
+			notContains: []string{`<path d="M0,0h`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg, err := qr.SVGString(NewQrCodeImgConfig(10, 0, tt.options...))
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, svg, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, svg, s)
+			}
+		})
+	}
+}
+
+func TestQrCode_writeOptimizedSVG_FinderStyle(t *testing.T) {
+	qr, err := EncodeText("https://github.com/piglig/go-qr", Medium)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	config := NewQrCodeImgConfig(1, 0, WithFinderStyle(FinderStyle{}, FinderTopRight))
+	match := regexp.MustCompile(`<path d="([^"]*)"`).FindStringSubmatch(optimizedSVG(t, qr, config))
+	if !assert.NotNil(t, match) {
+		return
+	}
+	loops := pathLoops(t, match[1])
+	for y := 0; y < qr.GetSize(); y++ {
+		for x := 0; x < qr.GetSize(); x++ {
+			corner, _, _, finder := qr.finderAt(x, y)
+			want := qr.GetModule(x, y) && !(finder && corner == FinderTopRight)
+			if insideEvenOdd(loops, float64(x)+0.5, float64(y)+0.5) != want {
+				t.Fatalf("module (%d, %d) is drawn wrong", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package go_qr
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"unicode"
+)
+
+// FrameTemplate is a decoration drawn around a QR code and its quiet zone by WithFrame.
+type FrameTemplate int
+
+const (
+	FrameBox  FrameTemplate = iota // Border around the QR code, with the caption outside it, which is the default
+	FrameTab                       // Border around the QR code, with the caption in a speech bubble tab pointing at it
+	FrameCard                      // Card with rounded corners around the QR code and the caption
+)
+
+// frameTemplateNames maps the FrameTemplate to its name.
+var frameTemplateNames = [...]string{"box", "tab", "card"}
+
+// String returns the name of the template, for example "tab".
+func (t FrameTemplate) String() string {
+	if t < FrameBox || t > FrameCard {
+		return "FrameTemplate(" + strconv.Itoa(int(t)) + ")"
+	}
+	return frameTemplateNames[t]
+}
+
+// CaptionPosition is the side of the QR code on which the caption of a frame is drawn.
+type CaptionPosition int
+
+const (
+	CaptionBelow CaptionPosition = iota // Below the QR code, which is the default
+	CaptionAbove                        // Above the QR code
+)
+
+// Frame is a decoration around a QR code, outside its quiet zone, with a caption line such as "SCAN ME".
+type Frame struct {
+	Template  FrameTemplate
+	Caption   string // Single line of text, or empty for none.
+	Position  CaptionPosition
+	Color     color.Color // Color of the border, tab or card, or nil for the dark color.
+	TextColor color.Color // Color of the caption, or nil for the light color on a tab or card and the frame color otherwise.
+	Width     int         // Width of the border, or of the card around the QR code, in modules, or 0 for 1.
+}
+
+// WithFrame returns a function that draws the frame around the QR code in PNG and SVG output in the provided
+// QrCodeImgConfig, which makes the output larger. The quiet zone stays light inside the frame. The caption is
+// drawn one module away from the frame in a bitmap font of 5x7 dots of half a module, in which characters
+// outside printable ASCII are drawn as '?'. SVG output draws it as text in a monospace font of the same size.
+// PNG output requires PNGColorRGBA, and Image and Draw leave the frame out.
+func WithFrame(frame Frame) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.frame = &frame
+	}
+}
+
+// validFrame checks the template, caption position, width and caption of the frame.
+func (q *QrCodeImgConfig) validFrame() error {
+	f := q.options.frame
+	switch {
+	case f == nil:
+		return nil
+	case f.Template < FrameBox || f.Template > FrameCard:
+		return &ConfigError{Field: "frame", Msg: "unknown frame template"}
+	case f.Position < CaptionBelow || f.Position > CaptionAbove:
+		return &ConfigError{Field: "frame", Msg: "unknown caption position"}
+	case f.Width < 0:
+		return &ConfigError{Field: "frame", Msg: "frame width must be non-negative"}
+	}
+	for _, r := range f.Caption {
+		if unicode.IsControl(r) {
+			return &ConfigError{Field: "frame", Msg: "frame caption must be a single line without control characters"}
+		}
+	}
+	return nil
+}
+
+// frameLayout is the position of a frame, its caption and the QR code with its quiet zone in an image, in pixels.
+type frameLayout struct {
+	frame         *Frame
+	width, height int
+	code          image.Rectangle // QR code with its quiet zone.
+	border        image.Rectangle // Outside of the border or of the card.
+	thickness     int             // Width of the border, or of the card around the QR code.
+	tab           image.Rectangle // Tab of FrameTab, or empty without a caption.
+	pointer       int             // Half the width of the pointer of the tab, which is as high as the gap to the border.
+	text          image.Point     // Top left corner of the caption.
+	dot           int             // Size of a dot of the font.
+}
+
+// newFrameLayout lays the frame out around a QR code of codeWidth by codeHeight pixels, including its quiet zone,
+// with modules of scale pixels. The QR code is centered, and the output is widened to fit the caption.
+func newFrameLayout(frame *Frame, codeWidth, codeHeight, scale int) *frameLayout {
+	l := &frameLayout{frame: frame, thickness: max(frame.Width, 1) * scale, dot: max(scale/2, 1)}
+	t := l.thickness
+	textWidth, band := 0, 0
+	if n := len([]rune(frame.Caption)); n > 0 {
+		textWidth = n*glyphAdvance*l.dot - l.dot
+		band = glyphHeight*l.dot + 2*scale
+	}
+
+	// The layout is computed with the caption below, and mirrored for a caption above.
+	code := image.Rect(0, 0, codeWidth, codeHeight)
+	switch frame.Template {
+	case FrameCard:
+		l.width = max(codeWidth, textWidth+2*scale) + 2*t
+		l.height = codeHeight + 2*t + band
+		l.border = image.Rect(0, 0, l.width, l.height)
+		l.code = code.Add(image.Pt((l.width-codeWidth)/2, t))
+		l.text = image.Pt((l.width-textWidth)/2, l.code.Max.Y+scale)
+	default:
+		l.width = max(codeWidth+2*t, textWidth+2*scale)
+		l.border = image.Rect(0, 0, codeWidth+2*t, codeHeight+2*t).Add(image.Pt((l.width-codeWidth-2*t)/2, 0))
+		l.code = code.Add(l.border.Min.Add(image.Pt(t, t)))
+		l.height = l.border.Max.Y + band
+		l.text = image.Pt((l.width-textWidth)/2, l.border.Max.Y+scale)
+		if frame.Template == FrameTab && band > 0 {
+			l.pointer = scale
+			l.tab = image.Rect(0, l.border.Max.Y+l.pointer, l.width, l.border.Max.Y+l.pointer+band)
+			l.height = l.tab.Max.Y
+			l.text.Y = l.tab.Min.Y + scale
+		}
+	}
+
+	if frame.Position == CaptionAbove {
+		flip := func(r image.Rectangle) image.Rectangle {
+			return image.Rect(r.Min.X, l.height-r.Max.Y, r.Max.X, l.height-r.Min.Y)
+		}
+		l.code, l.border, l.tab = flip(l.code), flip(l.border), flip(l.tab)
+		l.text.Y = l.height - l.text.Y - glyphHeight*l.dot
+	}
+	return l
+}
+
+// card returns the outline of the card of FrameCard, whose corner radius is twice its width around the QR code.
+func (l *frameLayout) card() roundedBox {
+	r := float64(min(2*l.thickness, min(l.width, l.height)/2))
+	b := l.border
+	return roundedBox{float64(b.Min.X), float64(b.Min.Y), float64(b.Dx()), float64(b.Dy()), [4]float64{r, r, r, r}}
+}
+
+// pointerEdges returns the y coordinates of the base of the pointer of the tab, on the tab, and of its tip,
+// on the border.
+func (l *frameLayout) pointerEdges() (base, tip int) {
+	if l.tab.Min.Y >= l.border.Max.Y {
+		return l.tab.Min.Y, l.border.Max.Y
+	}
+	return l.tab.Max.Y, l.border.Min.Y
+}
+
+// inPointer reports whether the point (px, py) is inside the pointer of the tab.
+func (l *frameLayout) inPointer(px, py float64) bool {
+	base, tip := l.pointerEdges()
+	f := (py - float64(tip)) / float64(base-tip)
+	return f >= 0 && f <= 1 && math.Abs(px-float64(l.width)/2) <= f*float64(l.pointer)
+}
+
+// colors returns the colors of the frame and of the caption in PNG output with QrCodeImgConfig.
+func (l *frameLayout) colors(config *QrCodeImgConfig) (frame, text color.Color) {
+	frame, text = l.frame.Color, l.frame.TextColor
+	if frame == nil {
+		frame = config.Dark()
+	}
+	if text == nil {
+		text = frame
+		if l.frame.Template != FrameBox {
+			text = config.Light()
+		}
+	}
+	return frame, text
+}
+
+// draw fills img with the light color of QrCodeImgConfig and draws the frame and the caption on it,
+// leaving the QR code to be drawn.
+func (l *frameLayout) draw(img *image.RGBA, config *QrCodeImgConfig) {
+	frameColor, textColor := l.colors(config)
+	draw.Draw(img, img.Bounds(), image.NewUniform(config.Light()), image.Point{}, draw.Src)
+	fill := image.NewUniform(frameColor)
+	switch l.frame.Template {
+	case FrameCard:
+		card := l.card()
+		for y := l.border.Min.Y; y < l.border.Max.Y; y++ {
+			for x := l.border.Min.X; x < l.border.Max.X; x++ {
+				if card.contains(float64(x)+0.5, float64(y)+0.5) && !(image.Point{X: x, Y: y}).In(l.code) {
+					img.Set(x, y, frameColor)
+				}
+			}
+		}
+	default:
+		b, t := l.border, l.thickness
+		for _, r := range []image.Rectangle{
+			image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Min.Y+t),
+			image.Rect(b.Min.X, b.Max.Y-t, b.Max.X, b.Max.Y),
+			image.Rect(b.Min.X, b.Min.Y+t, b.Min.X+t, b.Max.Y-t),
+			image.Rect(b.Max.X-t, b.Min.Y+t, b.Max.X, b.Max.Y-t),
+		} {
+			draw.Draw(img, r, fill, image.Point{}, draw.Src)
+		}
+		if !l.tab.Empty() {
+			draw.Draw(img, l.tab, fill, image.Point{}, draw.Src)
+			base, tip := l.pointerEdges()
+			for y := min(base, tip); y < max(base, tip); y++ {
+				for x := l.width/2 - l.pointer; x < l.width/2+l.pointer+1; x++ {
+					if l.inPointer(float64(x)+0.5, float64(y)+0.5) {
+						img.Set(x, y, frameColor)
+					}
+				}
+			}
+		}
+	}
+
+	text := image.NewUniform(textColor)
+	for i, r := range []rune(l.frame.Caption) {
+		g := glyph(r)
+		for gy := 0; gy < glyphHeight; gy++ {
+			for gx := 0; gx < glyphWidth; gx++ {
+				if glyphDot(g, gx, gy) {
+					dot := image.Rect(0, 0, l.dot, l.dot).Add(l.text.Add(image.Pt((i*glyphAdvance+gx)*l.dot, gy*l.dot)))
+					draw.Draw(img, dot, text, image.Point{}, draw.Src)
+				}
+			}
+		}
+	}
+}
+
+// svgCanvas returns the margins around a QR code of the given size in modules in SVG output, in pixels,
+// and the width and height of the output, which include the frame, and the layout of the frame or nil.
+func (q *QrCodeImgConfig) svgCanvas(size int) (Margins, int, int, *frameLayout) {
+	m := q.svgMargins()
+	width, height := q.svgSize(size)
+	if q.options.frame == nil {
+		return m, width, height, nil
+	}
+	l := newFrameLayout(q.options.frame, width, height, q.scale)
+	m.Left += l.code.Min.X
+	m.Top += l.code.Min.Y
+	m.Right += l.width - l.code.Max.X
+	m.Bottom += l.height - l.code.Max.Y
+	return m, l.width, l.height, l
+}
+
+// writeSVG writes the frame as one path, and the caption as text, in SVG output with QrCodeImgConfig.
+func (l *frameLayout) writeSVG(sw *svgWriter, config *QrCodeImgConfig) {
+	light, dark := config.svgPaints()
+	if config.svgGradient() != nil {
+		dark = newSVGPaint(config.Dark())
+	}
+	framePaint, textPaint := dark, light
+	if l.frame.Color != nil {
+		framePaint = newSVGPaint(l.frame.Color)
+	}
+	if l.frame.Template == FrameBox {
+		textPaint = framePaint
+	}
+	if l.frame.TextColor != nil {
+		textPaint = newSVGPaint(l.frame.TextColor)
+	}
+
+	box := func(r image.Rectangle) roundedBox {
+		return roundedBox{x: float64(r.Min.X), y: float64(r.Min.Y), w: float64(r.Dx()), h: float64(r.Dy())}
+	}
+	sw.writeString("\t<path d=\"")
+	if l.frame.Template == FrameCard {
+		writeRoundedBox(sw, l.card())
+		sw.writeString(" ")
+		writeRoundedBox(sw, box(l.code))
+	} else {
+		t := l.thickness
+		writeRoundedBox(sw, box(l.border))
+		sw.writeString(" ")
+		writeRoundedBox(sw, box(l.border.Inset(t)))
+		if !l.tab.Empty() {
+			base, tip := l.pointerEdges()
+			sw.writeString(" ")
+			writeRoundedBox(sw, box(l.tab))
+			center, half := float64(l.width)/2, float64(l.pointer)
+			sw.writeString(" M")
+			sw.writeFloat(center - half)
+			sw.printf(",%dL", base)
+			sw.writeFloat(center)
+			sw.printf(",%dL", tip)
+			sw.writeFloat(center + half)
+			sw.printf(",%dz", base)
+		}
+	}
+	sw.writeString("\" fill-rule=\"evenodd\"" + framePaint.attrs() + "/>\n")
+
+	if l.frame.Caption != "" {
+		// A monospace font of 10 dots has characters about 6 dots wide and capitals about 7 dots high,
+		// like the bitmap font.
+		sw.writeString("\t<text x=\"")
+		sw.writeFloat(float64(l.width) / 2)
+		sw.printf("\" y=\"%d\" font-family=\"monospace\" font-size=\"%d\" text-anchor=\"middle\"%s>%s</text>\n",
+			l.text.Y+glyphHeight*l.dot, 10*l.dot, textPaint.attrs(), svgText(l.frame.Caption))
+	}
+}
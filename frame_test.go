@@ -0,0 +1,181 @@
+package go_qr
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQrCodeImgConfig_validFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   Frame
+		wantErr bool
+	}{
+		{"box", Frame{Caption: "SCAN ME"}, false},
+		{"card above", Frame{Template: FrameCard, Caption: "Scan me", Position: CaptionAbove, Width: 2}, false},
+		{"no caption", Frame{Template: FrameTab}, false},
+		{"unknown template", Frame{Template: FrameCard + 1}, true},
+		{"unknown position", Frame{Position: CaptionAbove + 1}, true},
+		{"negative width", Frame{Width: -1}, true},
+		{"two lines", Frame{Caption: "SCAN\nME"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewQrCodeImgConfig(10, 4, WithFrame(tt.frame)).Valid()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalidConfig))
+			}
+		})
+	}
+}
+
+func TestFrameTemplate_String(t *testing.T) {
+	assert.Equal(t, "box", FrameBox.String())
+	assert.Equal(t, "card", FrameCard.String())
+	assert.Equal(t, "FrameTemplate(3)", FrameTemplate(3).String())
+}
+
+func TestNewFrameLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		frame  Frame
+		width  int
+		height int
+		code   image.Rectangle
+		text   image.Point
+	}{
+		// The caption of 7 characters is 7*6-1 dots of 5 pixels wide, and 7 dots high with a module above and below.
+		{"box", Frame{Caption: "SCAN ME"}, 310, 365, image.Rect(10, 10, 300, 300), image.Pt(52, 320)},
+		{"box above", Frame{Caption: "SCAN ME", Position: CaptionAbove}, 310, 365, image.Rect(10, 65, 300, 355), image.Pt(52, 10)},
+		{"box without caption", Frame{Width: 2}, 330, 330, image.Rect(20, 20, 310, 310), image.Pt(145, 340)},
+		{"tab", Frame{Template: FrameTab, Caption: "SCAN ME"}, 310, 375, image.Rect(10, 10, 300, 300), image.Pt(52, 330)},
+		{"card", Frame{Template: FrameCard, Caption: "SCAN ME"}, 310, 365, image.Rect(10, 10, 300, 300), image.Pt(52, 310)},
+		{"wide caption", Frame{Caption: "PLEASE SCAN THIS CODE"}, 645, 365, image.Rect(177, 10, 467, 300), image.Pt(10, 320)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newFrameLayout(&tt.frame, 290, 290, 10)
+			assert.Equal(t, tt.width, l.width)
+			assert.Equal(t, tt.height, l.height)
+			assert.Equal(t, tt.code, l.code)
+			if tt.frame.Caption != "" {
+				assert.Equal(t, tt.text, l.text)
+			}
+		})
+	}
+}
+
+func TestQrCode_WriteAsPNG_Frame(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	const scale, border = 10, 4
+	var plain bytes.Buffer
+	assert.NoError(t, qr.WriteAsPNG(NewQrCodeImgConfig(scale, border), &plain))
+	code, err := png.Decode(&plain)
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+
+	red := color.RGBA{R: 0xFF, A: 0xFF}
+	for _, frame := range []Frame{
+		{Caption: "SCAN ME", Color: red},
+		{Template: FrameTab, Caption: "SCAN ME", Color: red, Position: CaptionAbove},
+		{Template: FrameCard, Caption: "SCAN ME", Color: red, TextColor: color.Black},
+	} {
+		t.Run(frame.Template.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, qr.WriteAsPNG(NewQrCodeImgConfig(scale, border, WithFrame(frame)), &buf))
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+
+			width, height := NewQrCodeImgConfig(scale, border).pngSize(qr.GetSize())
+			l := newFrameLayout(&frame, width, height, scale)
+			assert.Equal(t, image.Rect(0, 0, l.width, l.height), img.Bounds())
+
+			// The QR code and its quiet zone are drawn as without a frame.
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					if color.RGBAModel.Convert(code.At(x, y)) != color.RGBAModel.Convert(img.At(l.code.Min.X+x, l.code.Min.Y+y)) {
+						t.Fatalf("pixel (%d, %d) of the QR code differs", x, y)
+					}
+				}
+			}
+
+			// The frame has its color, and the caption has dots of the text color.
+			edge := l.border.Min.Add(image.Pt(l.thickness/2, l.border.Dy()/2))
+			assert.Equal(t, color.RGBAModel.Convert(red), color.RGBAModel.Convert(img.At(edge.X, edge.Y)))
+			_, text := l.colors(NewQrCodeImgConfig(scale, border))
+			dots := 0
+			for y := l.text.Y; y < l.text.Y+glyphHeight*l.dot; y++ {
+				for x := l.text.X; x < l.width-l.text.X; x++ {
+					if color.RGBAModel.Convert(img.At(x, y)) == color.RGBAModel.Convert(text) {
+						dots++
+					}
+				}
+			}
+			assert.Greater(t, dots, 0)
+		})
+	}
+
+	err = qr.WriteAsPNG(NewQrCodeImgConfig(scale, border, WithFrame(Frame{}), WithPNGColorMode(PNGColorGray1)), &bytes.Buffer{})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
+
+func TestQrCode_SVGString_Frame(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	for _, options := range [][]func(*QrCodeImgConfig){
+		{WithFrame(Frame{Caption: "SCAN <ME>"})},
+		{WithFrame(Frame{Caption: "SCAN <ME>"}), WithOptimalSVG()},
+		{WithFrame(Frame{Caption: "SCAN <ME>"}), WithModuleShape(ShapeCircle)},
+	} {
+		svg, err := qr.SVGString(NewQrCodeImgConfig(10, 0, append(options, WithQuietZone(4))...))
+		assert.NoError(t, err)
+
+		// The QR code of 21 modules and its quiet zone are 290 pixels wide, with a border of 10 pixels around them,
+		// and 55 pixels for the caption below.
+		assert.Contains(t, svg, `viewBox="0 0 310 365"`)
+		assert.Contains(t, svg, "\t<path d=\"M0,0H310V310H0V0z M10,10H300V300H10V10z\" fill-rule=\"evenodd\" fill=\"#000000\"/>\n")
+		assert.Contains(t, svg, "\t<text x=\"155\" y=\"355\" font-family=\"monospace\" font-size=\"50\" text-anchor=\"middle\" fill=\"#000000\">SCAN &lt;ME&gt;</text>\n</svg>\n")
+
+		// The top left module is shifted by the border and the quiet zone.
+		match := regexp.MustCompile(`<path d="M([0-9.]+),([0-9.]+)`).FindStringSubmatch(svg)
+		x, _ := strconv.ParseFloat(match[1], 64)
+		y, _ := strconv.ParseFloat(match[2], 64)
+		assert.True(t, x >= 50 && x <= 60 && y >= 50 && y <= 60, match[0])
+	}
+
+	svg, err := qr.SVGString(NewQrCodeImgConfig(10, 0, WithQuietZone(4),
+		WithFrame(Frame{Template: FrameTab, Caption: "GO", Position: CaptionAbove, Color: color.RGBA{B: 0xFF, A: 0xFF}})))
+	assert.NoError(t, err)
+	assert.Contains(t, svg, `<path d="M0,65H310V375H0V65z M10,75H300V365H10V75z M0,0H310V55H0V0z M145,55L155,65L165,55z" fill-rule="evenodd" fill="#0000FF"/>`)
+	assert.Contains(t, svg, `<text x="155" y="45" font-family="monospace" font-size="50" text-anchor="middle" fill="#FFFFFF">GO</text>`)
+}
+
+func TestGlyph(t *testing.T) {
+	assert.Equal(t, glyph('?'), glyph('€'))
+	assert.Equal(t, glyph('?'), glyph('\t'))
+	assert.Equal(t, [glyphWidth]byte{}, glyph(' '))
+	// The vertical bar of 'T' spans all rows of the middle column.
+	for y := 0; y < glyphHeight; y++ {
+		assert.True(t, glyphDot(glyph('T'), 2, y))
+	}
+	assert.False(t, glyphDot(glyph('T'), 0, 1))
+}
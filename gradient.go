@@ -0,0 +1,244 @@
+package go_qr
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// MinContrast is the contrast ratio, as defined by WCAG, between the dark modules and the light color
+// below which Warnings reports a gradient.
+const MinContrast = 3
+
+// GradientStop is a color of a gradient at an offset between 0 and 1 along it.
+type GradientStop struct {
+	Offset float64
+	Color  color.Color
+}
+
+// gradientKind is the geometry of a gradient.
+type gradientKind int
+
+const (
+	linearGradient gradientKind = iota
+	radialGradient
+)
+
+// gradient fills the dark modules with colors which change over the QR code, without its quiet zone.
+type gradient struct {
+	kind  gradientKind
+	angle float64 // Direction of a linear gradient in degrees, clockwise from left to right.
+	stops []GradientStop
+}
+
+// WithLinearGradient returns a function that fills the dark modules with a linear gradient through the stops
+// in PNG and SVG output in the provided QrCodeImgConfig, replacing the dark color. The gradient runs at angle
+// degrees clockwise from left to right, so that 90 runs from top to bottom, across the whole QR code without
+// its quiet zone. The colors between the stops are interpolated without premultiplied alpha, as in SVG.
+func WithLinearGradient(angle float64, stops ...GradientStop) func(*QrCodeImgConfig) {
+	g := &gradient{kind: linearGradient, angle: angle, stops: append([]GradientStop(nil), stops...)}
+	return func(q *QrCodeImgConfig) {
+		q.options.gradient = g
+	}
+}
+
+// WithRadialGradient returns a function that fills the dark modules with a radial gradient through the stops
+// in PNG and SVG output in the provided QrCodeImgConfig, replacing the dark color. The gradient runs from the
+// center of the QR code at offset 0 to its corners at offset 1.
+func WithRadialGradient(stops ...GradientStop) func(*QrCodeImgConfig) {
+	g := &gradient{kind: radialGradient, stops: append([]GradientStop(nil), stops...)}
+	return func(q *QrCodeImgConfig) {
+		q.options.gradient = g
+	}
+}
+
+// validGradient checks that the gradient has at least two stops with colors, at offsets between 0 and 1
+// in increasing order.
+func (q *QrCodeImgConfig) validGradient() error {
+	g := q.options.gradient
+	if g == nil {
+		return nil
+	}
+	if math.IsNaN(g.angle) || math.IsInf(g.angle, 0) {
+		return &ConfigError{Field: "gradient", Msg: "gradient angle must be finite"}
+	}
+	if len(g.stops) < 2 {
+		return &ConfigError{Field: "gradient", Msg: "gradient needs at least two stops"}
+	}
+	for i, stop := range g.stops {
+		if stop.Color == nil {
+			return &ConfigError{Field: "gradient", Msg: fmt.Sprintf("gradient stop %d has no color", i)}
+		}
+		if !(stop.Offset >= 0 && stop.Offset <= 1) {
+			return &ConfigError{Field: "gradient", Msg: fmt.Sprintf("gradient stop %d has an offset outside 0 and 1", i)}
+		}
+		if i > 0 && stop.Offset < g.stops[i-1].Offset {
+			return &ConfigError{Field: "gradient", Msg: fmt.Sprintf("gradient stop %d is before the previous stop", i)}
+		}
+	}
+	return nil
+}
+
+// gradientWarnings returns a warning matching ErrLowContrast if any color of the gradient, drawn over
+// the light color, has a contrast ratio below MinContrast against it. Only the lowest contrast is reported.
+func (q *QrCodeImgConfig) gradientWarnings() []error {
+	g := q.options.gradient
+	if g == nil || q.validGradient() != nil {
+		return nil
+	}
+
+	// The light color is taken as printed on white paper.
+	white := color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	light := blendOver(color.NRGBAModel.Convert(q.Light()).(color.NRGBA), white)
+	lightLuminance := relativeLuminance(light)
+
+	// The colors between two stops are sampled finely enough to find the lowest contrast of 8-bit colors.
+	const samples = 256
+	worst, worstOffset := math.Inf(1), 0.0
+	for i := 1; i < len(g.stops); i++ {
+		from, to := g.stops[i-1].Offset, g.stops[i].Offset
+		for j := 0; j <= samples; j++ {
+			offset := from + (to-from)*float64(j)/samples
+			c := blendOver(g.colorAt(offset), light)
+			if contrast := contrastRatio(relativeLuminance(c), lightLuminance); contrast < worst {
+				worst, worstOffset = contrast, offset
+			}
+		}
+	}
+	if worst >= MinContrast {
+		return nil
+	}
+	return []error{fmt.Errorf("%w: gradient contrast ratio of %.2f against the light color at offset %.2f is less than the minimum of %d",
+		ErrLowContrast, worst, worstOffset, MinContrast)}
+}
+
+// blendOver returns c drawn over the opaque color background.
+func blendOver(c, background color.NRGBA) color.NRGBA {
+	a := float64(c.A) / 0xFF
+	blend := func(v, b uint8) uint8 {
+		return uint8(math.Round(float64(v)*a + float64(b)*(1-a)))
+	}
+	return color.NRGBA{R: blend(c.R, background.R), G: blend(c.G, background.G), B: blend(c.B, background.B), A: 0xFF}
+}
+
+// relativeLuminance returns the relative luminance of an opaque sRGB color as defined by WCAG.
+func relativeLuminance(c color.NRGBA) float64 {
+	linear := func(v uint8) float64 {
+		s := float64(v) / 0xFF
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+// contrastRatio returns the contrast ratio between two relative luminances, which is between 1 and 21.
+func contrastRatio(l1, l2 float64) float64 {
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// colorAt returns the color of the gradient at offset. Before the first and after the last stop, the colors
+// of those stops are kept. At two stops with the same offset, the color changes abruptly.
+func (g *gradient) colorAt(offset float64) color.NRGBA {
+	stops := g.stops
+	if offset <= stops[0].Offset {
+		return color.NRGBAModel.Convert(stops[0].Color).(color.NRGBA)
+	}
+	for i := 1; i < len(stops); i++ {
+		if offset >= stops[i].Offset {
+			continue
+		}
+		from := color.NRGBAModel.Convert(stops[i-1].Color).(color.NRGBA)
+		to := color.NRGBAModel.Convert(stops[i].Color).(color.NRGBA)
+		f := (offset - stops[i-1].Offset) / (stops[i].Offset - stops[i-1].Offset)
+		mix := func(a, b uint8) uint8 {
+			return uint8(math.Round(float64(a) + (float64(b)-float64(a))*f))
+		}
+		return color.NRGBA{R: mix(from.R, to.R), G: mix(from.G, to.G), B: mix(from.B, to.B), A: mix(from.A, to.A)}
+	}
+	return color.NRGBAModel.Convert(stops[len(stops)-1].Color).(color.NRGBA)
+}
+
+// direction returns the unit vector of a linear gradient, and half its length relative to the width of
+// the QR code, so that it reaches exactly into the corners of the QR code.
+func (g *gradient) direction() (dx, dy, half float64) {
+	rad := g.angle * math.Pi / 180
+	dx, dy = math.Cos(rad), math.Sin(rad)
+	return dx, dy, (math.Abs(dx) + math.Abs(dy)) / 2
+}
+
+// offsetAt returns the offset of the gradient at the point (u, v) of the QR code, where u and v are
+// between 0 and 1 from the top left corner of the QR code without its quiet zone.
+func (g *gradient) offsetAt(u, v float64) float64 {
+	u, v = u-0.5, v-0.5
+	if g.kind == radialGradient {
+		return math.Hypot(u, v) * math.Sqrt2
+	}
+	dx, dy, half := g.direction()
+	return (u*dx + v*dy + half) / (2 * half)
+}
+
+// writeSVGDefs writes the definition of the gradient for an SVG image in which the QR code without
+// its quiet zone has the given size in pixels and its top left corner at (x, y).
+func (g *gradient) writeSVGDefs(sw *svgWriter, id string, x, y, size float64) {
+	sw.writeString("\t<defs>\n")
+	if g.kind == radialGradient {
+		sw.printf("\t\t<radialGradient id=\"%s\" gradientUnits=\"userSpaceOnUse\" cx=\"", svgText(id))
+		sw.writeFloat(x + size/2)
+		sw.writeString("\" cy=\"")
+		sw.writeFloat(y + size/2)
+		sw.writeString("\" r=\"")
+		sw.writeFloat(size / math.Sqrt2)
+		sw.writeString("\">\n")
+	} else {
+		dx, dy, half := g.direction()
+		sw.printf("\t\t<linearGradient id=\"%s\" gradientUnits=\"userSpaceOnUse\" x1=\"", svgText(id))
+		sw.writeFloat(x + size*(0.5-dx*half))
+		sw.writeString("\" y1=\"")
+		sw.writeFloat(y + size*(0.5-dy*half))
+		sw.writeString("\" x2=\"")
+		sw.writeFloat(x + size*(0.5+dx*half))
+		sw.writeString("\" y2=\"")
+		sw.writeFloat(y + size*(0.5+dy*half))
+		sw.writeString("\">\n")
+	}
+	for _, stop := range g.stops {
+		c := color.NRGBAModel.Convert(stop.Color).(color.NRGBA)
+		sw.writeString("\t\t\t<stop offset=\"")
+		sw.writeFloat(stop.Offset)
+		sw.printf("\" stop-color=\"#%02X%02X%02X\"", c.R, c.G, c.B)
+		if c.A != 0xFF {
+			sw.writeString(" stop-opacity=\"" + strconv.FormatFloat(math.Round(float64(c.A)/0xFF*1000)/1000, 'f', -1, 64) + "\"")
+		}
+		sw.writeString("/>\n")
+	}
+	if g.kind == radialGradient {
+		sw.writeString("\t\t</radialGradient>\n")
+	} else {
+		sw.writeString("\t\t</linearGradient>\n")
+	}
+	sw.writeString("\t</defs>\n")
+}
+
+// svgGradient returns the gradient of the dark modules in SVG output, or nil if it is replaced by
+// WithSVGClasses or WithSVGColors.
+func (q *QrCodeImgConfig) svgGradient() *gradient {
+	if q.options.svgClasses != nil || q.options.svgColors != nil {
+		return nil
+	}
+	return q.options.gradient
+}
+
+// svgGradientID returns the id of the gradient in SVG output, which is derived from the id of the svg element
+// if it has one, so that several QR codes can be inlined in one document.
+func (q *QrCodeImgConfig) svgGradientID() string {
+	if q.options.svgID != "" {
+		return q.options.svgID + "-gradient"
+	}
+	return "qr-gradient"
+}
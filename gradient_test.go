@@ -0,0 +1,192 @@
+package go_qr
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var blackToBlue = []GradientStop{{0, color.Black}, {1, color.RGBA{B: 0xFF, A: 0xFF}}}
+
+func TestQrCodeImgConfig_validGradient(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*QrCodeImgConfig)
+		wantErr bool
+	}{
+		{"linear", []func(*QrCodeImgConfig){WithLinearGradient(45, blackToBlue...)}, false},
+		{"radial", []func(*QrCodeImgConfig){WithRadialGradient(blackToBlue...)}, false},
+		{"same offsets", []func(*QrCodeImgConfig){WithRadialGradient(GradientStop{0, color.Black}, GradientStop{0.5, color.Black}, GradientStop{0.5, color.Black})}, false},
+		{"one stop", []func(*QrCodeImgConfig){WithLinearGradient(0, GradientStop{0, color.Black})}, true},
+		{"no color", []func(*QrCodeImgConfig){WithLinearGradient(0, GradientStop{0, color.Black}, GradientStop{1, nil})}, true},
+		{"offset out of range", []func(*QrCodeImgConfig){WithLinearGradient(0, GradientStop{0, color.Black}, GradientStop{1.5, color.Black})}, true},
+		{"offsets out of order", []func(*QrCodeImgConfig){WithLinearGradient(0, GradientStop{0.5, color.Black}, GradientStop{0.2, color.Black})}, true},
+		{"infinite angle", []func(*QrCodeImgConfig){WithLinearGradient(math.Inf(1), blackToBlue...)}, true},
+		{"paletted PNG", []func(*QrCodeImgConfig){WithLinearGradient(0, blackToBlue...), WithPNGColorMode(PNGColorPaletted)}, true},
+	}
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := qr.WriteAsPNG(NewQrCodeImgConfig(10, 4, tt.options...), &bytes.Buffer{})
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalidConfig))
+			}
+		})
+	}
+}
+
+func TestQrCodeImgConfig_Warnings_Gradient(t *testing.T) {
+	tests := []struct {
+		name      string
+		light     color.Color
+		stops     []GradientStop
+		wantCount int
+	}{
+		{"dark", color.White, blackToBlue, 0},
+		{"light middle", color.White, []GradientStop{{0, color.Black}, {0.5, color.Gray{Y: 0xE0}}, {1, color.Black}}, 1},
+		{"translucent", color.White, []GradientStop{{0, color.Black}, {1, color.NRGBA{A: 0x20}}}, 1},
+		{"dark light color", color.Black, blackToBlue, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewQrCodeImgConfig(10, 0, WithQuietZone(4), WithLinearGradient(0, tt.stops...), WithStrictQuietZone())
+			config.SetLight(tt.light)
+			warnings := config.Warnings()
+			assert.Len(t, warnings, tt.wantCount)
+			for _, w := range warnings {
+				assert.ErrorIs(t, w, ErrLowContrast)
+			}
+			assert.NoError(t, config.Valid())
+		})
+	}
+}
+
+func TestGradient_offsetAt(t *testing.T) {
+	tests := []struct {
+		name string
+		g    *gradient
+		u, v float64
+		want float64
+	}{
+		{"left", &gradient{kind: linearGradient}, 0, 0.5, 0},
+		{"right", &gradient{kind: linearGradient}, 1, 0.3, 1},
+		{"top to bottom", &gradient{kind: linearGradient, angle: 90}, 0.2, 0.25, 0.25},
+		{"diagonal top left", &gradient{kind: linearGradient, angle: 45}, 0, 0, 0},
+		{"diagonal center", &gradient{kind: linearGradient, angle: 45}, 0.5, 0.5, 0.5},
+		{"diagonal bottom right", &gradient{kind: linearGradient, angle: 45}, 1, 1, 1},
+		{"radial center", &gradient{kind: radialGradient}, 0.5, 0.5, 0},
+		{"radial corner", &gradient{kind: radialGradient}, 1, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, tt.g.offsetAt(tt.u, tt.v), 1e-9)
+		})
+	}
+}
+
+func TestGradient_colorAt(t *testing.T) {
+	g := &gradient{stops: []GradientStop{{0.2, color.Black}, {0.6, color.NRGBA{R: 200, A: 100}}, {0.6, color.White}}}
+	assert.Equal(t, color.NRGBA{A: 0xFF}, g.colorAt(0))
+	assert.Equal(t, color.NRGBA{R: 100, A: 178}, g.colorAt(0.4))
+	assert.Equal(t, color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}, g.colorAt(0.6))
+	assert.Equal(t, color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}, g.colorAt(1))
+}
+
+func TestQrCode_WriteAsPNG_Gradient(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	const scale, border = 10, 2
+	for _, shape := range []ModuleShape{ShapeSquare, ShapeCircle} {
+		t.Run(shape.String(), func(t *testing.T) {
+			config := NewQrCodeImgConfig(scale, border, WithLinearGradient(0, blackToBlue...), WithModuleShape(shape))
+			var buf bytes.Buffer
+			assert.NoError(t, qr.WriteAsPNG(config, &buf))
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+
+			view, err := qr.Image(config)
+			assert.NoError(t, err)
+			bounds := img.Bounds()
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					if color.NRGBAModel.Convert(img.At(x, y)) != color.NRGBAModel.Convert(view.At(x, y)) {
+						t.Fatalf("pixel (%d, %d) of the PNG and the image differ", x, y)
+					}
+				}
+			}
+
+			// The center of the top left and top right finder patterns.
+			left := color.NRGBAModel.Convert(img.At(border*scale+35, border*scale+35)).(color.NRGBA)
+			right := color.NRGBAModel.Convert(img.At((border+qr.GetSize())*scale-35, border*scale+35)).(color.NRGBA)
+			assert.Less(t, left.B, uint8(0x30))
+			assert.Greater(t, right.B, uint8(0xD0))
+			assert.Equal(t, color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}, color.NRGBAModel.Convert(img.At(0, 0)))
+		})
+	}
+}
+
+func TestQrCode_SVGString_Gradient(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		options     []func(*QrCodeImgConfig)
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:    "linear",
+			options: []func(*QrCodeImgConfig){WithLinearGradient(0, blackToBlue...)},
+			contains: []string{
+				"\t<defs>\n\t\t<linearGradient id=\"qr-gradient\" gradientUnits=\"userSpaceOnUse\" x1=\"0\" y1=\"105\" x2=\"210\" y2=\"105\">\n" +
+					"\t\t\t<stop offset=\"0\" stop-color=\"#000000\"/>\n\t\t\t<stop offset=\"1\" stop-color=\"#0000FF\"/>\n" +
+					"\t\t</linearGradient>\n\t</defs>\n",
+				`" fill="url(#qr-gradient)"/>`,
+			},
+		},
+		{
+			name:     "radial",
+			options:  []func(*QrCodeImgConfig){WithOptimalSVG(), WithSVGID("code"), WithRadialGradient(GradientStop{0, color.Black}, GradientStop{1, color.NRGBA{R: 0xFF, A: 0x80}})},
+			contains: []string{`<radialGradient id="code-gradient" gradientUnits="userSpaceOnUse" cx="105" cy="105" r="148.492">`, `stop-color="#FF0000" stop-opacity="0.502"/>`, `" fill="url(#code-gradient)"/>`},
+		},
+		{
+			name:     "finder style",
+			options:  []func(*QrCodeImgConfig){WithLinearGradient(0, blackToBlue...), WithFinderStyle(FinderStyle{EyeColor: color.Black})},
+			contains: []string{`fill-rule="evenodd" fill="url(#qr-gradient)"/>`, `" fill="#000000"/>`},
+		},
+		{
+			name:        "CSS colors",
+			options:     []func(*QrCodeImgConfig){WithLinearGradient(0, blackToBlue...), WithSVGColors("white", "black")},
+			notContains: []string{"<defs>", "url("},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg, err := qr.SVGString(NewQrCodeImgConfig(10, 0, tt.options...))
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, svg, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, svg, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package go_qr
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// halftoneSubpixels is the number of sub-pixels per module side of a halftone.
+const halftoneSubpixels = 3
+
+// WithHalftone returns a function that draws the QR code as a halftone of img in PNG and SVG output
+// in the provided QrCodeImgConfig. Each module is split into 3x3 sub-pixels: the center one has the color
+// of the module, and the others show the picture, dithered to the dark and light colors with Floyd-Steinberg
+// error diffusion. The function patterns are drawn whole. The picture is cropped to a square in its center
+// and scaled to the QR code without its quiet zone. The scale should be a multiple of 3 pixels.
+// EncodeSegmentsForHalftone chooses a mask which agrees with the picture. A halftone requires ShapeSquare,
+// and cannot be combined with WithFinderStyle or WithModuleColorFunc.
+func WithHalftone(img image.Image) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.halftone = img
+	}
+}
+
+// validHalftone checks that the halftone has a picture and only uses square modules.
+func (q *QrCodeImgConfig) validHalftone() error {
+	switch {
+	case q.options.halftone == nil:
+		return nil
+	case q.options.halftone.Bounds().Empty():
+		return &ConfigError{Field: "halftone", Msg: "halftone image is empty"}
+	case q.options.moduleShape != ShapeSquare:
+		return &ConfigError{Field: "halftone", Msg: "halftone requires square modules"}
+	case q.options.finderStyles != [finderCount]*FinderStyle{}:
+		return &ConfigError{Field: "halftone", Msg: "halftone cannot be combined with finder pattern styles"}
+	case q.options.moduleColorFunc != nil:
+		return &ConfigError{Field: "halftone", Msg: "halftone cannot be combined with module colors"}
+	}
+	return nil
+}
+
+// EncodeSegmentsForHalftone is like EncodeSegmentsWithPolicy with mask -1, but chooses the mask in which
+// the data modules agree best with img, cropped and scaled as by WithHalftone, instead of the mask with
+// the lowest penalty score, so that fewer module centers stand out from the picture.
+func EncodeSegmentsForHalftone(segs []*QrSegment, ecl Ecc, minVer, maxVer int, policy EncodePolicy, img image.Image) (*QrCode, error) {
+	if img == nil || img.Bounds().Empty() {
+		return nil, fmt.Errorf("%w: halftone image is empty", ErrInvalidArgument)
+	}
+	return encodeSegments(segs, ecl, minVer, maxVer, -1, policy, halftoneMaskScore(img))
+}
+
+// halftoneMaskScore returns a score of masked QR codes, which is the sum of how far the picture is from
+// the middle gray over the data modules whose color differs from the picture.
+func halftoneMaskScore(img image.Image) func(*QrCode) int {
+	var lightness [][]float64
+	return func(q *QrCode) int {
+		if lightness == nil {
+			lightness = sampleLightness(img, q.size)
+		}
+		score := 0
+		for y, row := range lightness {
+			for x, l := range row {
+				if !q.isFunction[y][x] && q.modules[y][x] != (l < 0.5) {
+					score += int(math.Round(math.Abs(l-0.5) * 2 * 0xFF))
+				}
+			}
+		}
+		return score
+	}
+}
+
+// sampleLightness crops img to a square in its center, and returns the average lightness of the pixels in
+// each cell of an n by n grid over it, between 0 for black and 1 for white. Transparent pixels are white.
+func sampleLightness(img image.Image, n int) [][]float64 {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	left, top := bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2
+	// Each cell covers the pixels from the first to the last pixel it starts, or at least one pixel.
+	span := func(i int) (int, int) {
+		from, to := i*side/n, (i+1)*side/n
+		return from, max(to, from+1)
+	}
+
+	grid := make([][]float64, n)
+	for y := range grid {
+		grid[y] = make([]float64, n)
+		y0, y1 := span(y)
+		for x := range grid[y] {
+			x0, x1 := span(x)
+			sum := 0.0
+			for py := y0; py < y1; py++ {
+				for px := x0; px < x1; px++ {
+					r, g, b, a := img.At(left+px, top+py).RGBA()
+					sum += (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b) + float64(0xFFFF-a)) / 0xFFFF
+				}
+			}
+			grid[y][x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+	return grid
+}
+
+// newHalftone returns the dark sub-pixels of a halftone of img over the QR code, indexed as [y][x].
+// The center sub-pixels of the modules are fixed to the color of their module, and their error is diffused
+// over their neighbours like that of the picture. The function patterns and the area of the logo, if any,
+// are drawn whole.
+func newHalftone(q *QrCode, img image.Image, logo *Logo) [][]bool {
+	roles := q.ModuleRoles()
+	logoStart, logoEnd := 0, 0
+	if logo != nil {
+		start, n := logoArea(q.size, *logo)
+		logoStart, logoEnd = start, start+n
+	}
+	inLogo := func(x, y int) bool {
+		return x >= logoStart && x < logoEnd && y >= logoStart && y < logoEnd
+	}
+	n := q.size * halftoneSubpixels
+	lightness := sampleLightness(img, n)
+	dark := make([][]bool, n)
+	for y := range dark {
+		dark[y] = make([]bool, n)
+	}
+	diffuse := func(x, y int, e float64) {
+		if x >= 0 && x < n && y < n {
+			lightness[y][x] += e
+		}
+	}
+
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			moduleX, moduleY := x/halftoneSubpixels, y/halftoneSubpixels
+			center := x%halftoneSubpixels == halftoneSubpixels/2 && y%halftoneSubpixels == halftoneSubpixels/2
+			if roles[moduleY][moduleX].IsFunction() || inLogo(moduleX, moduleY) {
+				dark[y][x] = q.GetModule(moduleX, moduleY)
+				continue
+			}
+			l := lightness[y][x]
+			if center {
+				dark[y][x] = q.GetModule(moduleX, moduleY)
+			} else {
+				dark[y][x] = l < 0.5
+			}
+			if !dark[y][x] {
+				l--
+			}
+			diffuse(x+1, y, l*7/16)
+			diffuse(x-1, y+1, l*3/16)
+			diffuse(x, y+1, l*5/16)
+			diffuse(x+1, y+1, l*1/16)
+		}
+	}
+	return dark
+}
+
+// halftoneDark reports whether the point (fx, fy) of the module at (x, y) is in a dark sub-pixel of the halftone
+// of the style, where fx and fy are between 0 and 1 from the top left corner of the module.
+func (s *moduleStyle) halftoneDark(x, y int, fx, fy float64) bool {
+	if x < 0 || y < 0 || x >= s.qr.size || y >= s.qr.size {
+		return false
+	}
+	sx := min(int(fx*halftoneSubpixels), halftoneSubpixels-1)
+	sy := min(int(fy*halftoneSubpixels), halftoneSubpixels-1)
+	return s.halftone[y*halftoneSubpixels+sy][x*halftoneSubpixels+sx]
+}
+
+// writeHalftonePath writes a path of the dark sub-pixels of the halftone of the style, with margins in pixels,
+// in which the sub-pixels of each row are joined into runs.
+func (s *moduleStyle) writeHalftonePath(sw *svgWriter, margins Margins, dark svgPaint) {
+	sub := float64(s.scale) / halftoneSubpixels
+	sw.writeString("\t<path d=\"")
+	sep := ""
+	for y, row := range s.halftone {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			end := x
+			for end < len(row) && row[end] {
+				end++
+			}
+			sw.writeString(sep + "M")
+			sw.writeFloat(float64(margins.Left) + float64(x)*sub)
+			sw.writeString(",")
+			sw.writeFloat(float64(margins.Top) + float64(y)*sub)
+			sw.writeString("h")
+			sw.writeFloat(float64(end-x) * sub)
+			sw.writeString("v")
+			sw.writeFloat(sub)
+			sw.writeString("h")
+			sw.writeFloat(-float64(end-x) * sub)
+			sw.writeString("z")
+			sep = " "
+			x = end
+		}
+	}
+	sw.writeString("\"" + dark.attrs() + "/>\n")
+}
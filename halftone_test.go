@@ -0,0 +1,237 @@
+package go_qr
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testPicture returns a gray picture of the given size with a black disc in its middle.
+func testPicture(w, h int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := x-w/2, y-h/2
+			if dx*dx+dy*dy < w*h/8 {
+				continue
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(0x40 + 0x80*x/w)})
+		}
+	}
+	return img
+}
+
+func TestQrCodeImgConfig_validHalftone(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*QrCodeImgConfig)
+		wantErr bool
+	}{
+		{"square", []func(*QrCodeImgConfig){WithHalftone(testPicture(8, 8))}, false},
+		{"with logo", []func(*QrCodeImgConfig){WithHalftone(testPicture(8, 8)), WithLogo(Logo{SVG: "<g/>", Size: 0.2})}, false},
+		{"none", []func(*QrCodeImgConfig){WithHalftone(nil)}, false},
+		{"empty", []func(*QrCodeImgConfig){WithHalftone(image.NewGray(image.Rect(0, 0, 0, 4)))}, true},
+		{"circle", []func(*QrCodeImgConfig){WithHalftone(testPicture(8, 8)), WithModuleShape(ShapeCircle)}, true},
+		{"finder style", []func(*QrCodeImgConfig){WithHalftone(testPicture(8, 8)), WithFinderStyle(FinderStyle{})}, true},
+		{"module colors", []func(*QrCodeImgConfig){WithHalftone(testPicture(8, 8)),
+			WithModuleColorFunc(func(x, y int, dark bool, role ModuleRole) color.Color { return nil })}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewQrCodeImgConfig(9, 4, tt.options...).Valid()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalidConfig))
+			}
+		})
+	}
+}
+
+func TestSampleLightness(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 6, 4))
+	// The picture is cropped to the square from x=1 to x=5, whose left half is black and right half transparent.
+	for y := 0; y < 4; y++ {
+		img.Set(0, y, color.White)
+		img.Set(1, y, color.Black)
+		img.Set(2, y, color.Black)
+		img.Set(5, y, color.Black)
+	}
+	assert.Equal(t, [][]float64{{0, 1}, {0, 1}}, sampleLightness(img, 2))
+	// Cells smaller than a pixel repeat it.
+	grid := sampleLightness(img, 8)
+	assert.Equal(t, 0.0, grid[7][3])
+	assert.Equal(t, 1.0, grid[0][4])
+}
+
+func TestNewHalftone(t *testing.T) {
+	qr, err := EncodeText("https://github.com/piglig/go-qr", Medium)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	size := qr.GetSize()
+	roles := qr.ModuleRoles()
+
+	black, white := image.NewGray(image.Rect(0, 0, 4, 4)), image.NewGray(image.Rect(0, 0, 4, 4))
+	for i := range white.Pix {
+		white.Pix[i] = 0xFF
+	}
+	for _, img := range []image.Image{black, white, testPicture(100, 80)} {
+		dark := newHalftone(qr, img, nil)
+		assert.Len(t, dark, size*halftoneSubpixels)
+		darkData, data := 0, 0
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				for sy := 0; sy < halftoneSubpixels; sy++ {
+					for sx := 0; sx < halftoneSubpixels; sx++ {
+						d := dark[y*halftoneSubpixels+sy][x*halftoneSubpixels+sx]
+						center := sx == halftoneSubpixels/2 && sy == halftoneSubpixels/2
+						if center || roles[y][x].IsFunction() {
+							if d != qr.GetModule(x, y) {
+								t.Fatalf("sub-pixel (%d, %d) of module (%d, %d) differs from the module", sx, sy, x, y)
+							}
+						} else if d {
+							darkData++
+						}
+						if !center && !roles[y][x].IsFunction() {
+							data++
+						}
+					}
+				}
+			}
+		}
+		// The sub-pixels around the centers follow the picture, so a black picture is mostly dark and a white one light.
+		switch img {
+		case black:
+			assert.Greater(t, darkData, data*9/10)
+		case white:
+			assert.Less(t, darkData, data/10)
+		}
+	}
+
+	// The modules under a logo are drawn whole.
+	logo := &Logo{SVG: "<g/>", Size: 0.2}
+	start, n := logoArea(size, *logo)
+	dark := newHalftone(qr.withLogoCleared(NewQrCodeImgConfig(9, 4, WithLogo(*logo))), black, logo)
+	for y := start * halftoneSubpixels; y < (start+n)*halftoneSubpixels; y++ {
+		for x := start * halftoneSubpixels; x < (start+n)*halftoneSubpixels; x++ {
+			assert.False(t, dark[y][x], "sub-pixel (%d, %d)", x, y)
+		}
+	}
+}
+
+func TestQrCode_WriteAsPNG_Halftone(t *testing.T) {
+	qr, err := EncodeText("https://github.com/piglig/go-qr", Medium)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	const scale, border = 9, 2
+	config := NewQrCodeImgConfig(scale, border, WithHalftone(testPicture(64, 64)))
+	var buf bytes.Buffer
+	assert.NoError(t, qr.WriteAsPNG(config, &buf))
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+
+	// Each sub-pixel is 3 pixels wide, and its color is the one of the halftone.
+	dark := newHalftone(qr, testPicture(64, 64), nil)
+	for y, row := range dark {
+		for x, d := range row {
+			want := color.GrayModel.Convert(config.Light())
+			if d {
+				want = color.GrayModel.Convert(config.Dark())
+			}
+			px, py := border*scale+x*3+1, border*scale+y*3+1
+			if c := color.GrayModel.Convert(img.At(px, py)); c != want {
+				t.Fatalf("sub-pixel (%d, %d) is %v", x, y, c)
+			}
+		}
+	}
+
+	view, err := qr.Image(config)
+	assert.NoError(t, err)
+	bounds := img.Bounds()
+	assert.Equal(t, bounds, view.Bounds())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if color.GrayModel.Convert(img.At(x, y)) != color.GrayModel.Convert(view.At(x, y)) {
+				t.Fatalf("pixel (%d, %d) of the PNG and the image differ", x, y)
+			}
+		}
+	}
+}
+
+func TestQrCode_SVGString_Halftone(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	dark := newHalftone(qr, testPicture(50, 50), nil)
+
+	for _, options := range [][]func(*QrCodeImgConfig){
+		{WithHalftone(testPicture(50, 50))},
+		{WithHalftone(testPicture(50, 50)), WithOptimalSVG()},
+	} {
+		svg, err := qr.SVGString(NewQrCodeImgConfig(9, 0, options...))
+		assert.NoError(t, err)
+
+		// The sub-pixels are 3 pixels wide, and the path covers exactly the dark ones.
+		paths := regexp.MustCompile(`<path d="([^"]*)"`).FindAllStringSubmatch(svg, -1)
+		assert.Len(t, paths, 1)
+		loops := pathLoops(t, paths[0][1])
+		for y, row := range dark {
+			for x, d := range row {
+				assert.Equal(t, d, insideEvenOdd(loops, float64(x*3)+1.5, float64(y*3)+1.5), "sub-pixel (%d, %d)", x, y)
+			}
+		}
+	}
+}
+
+func TestEncodeSegmentsForHalftone(t *testing.T) {
+	segs, err := MakeSegments("https://github.com/piglig/go-qr")
+	if err != nil {
+		t.Fatalf("MakeSegments() error = %v", err)
+	}
+	img := testPicture(120, 120)
+	qr, err := EncodeSegmentsForHalftone(segs, Medium, MinVersion, MaxVersion, SmallestVersion, img)
+	if err != nil {
+		t.Fatalf("EncodeSegmentsForHalftone() error = %v", err)
+	}
+
+	// score returns the score of halftoneMaskScore, with the function modules of the finished QR code.
+	score := func(q *QrCode) int {
+		roles := q.ModuleRoles()
+		q.isFunction = make([][]bool, q.size)
+		for y, row := range roles {
+			q.isFunction[y] = make([]bool, q.size)
+			for x, role := range row {
+				q.isFunction[y][x] = role.IsFunction()
+			}
+		}
+		defer func() { q.isFunction = nil }()
+		return halftoneMaskScore(img)(q)
+	}
+
+	// The chosen mask agrees best with the picture, and the QR code is the one encoded with that mask.
+	best := score(qr)
+	for mask := 0; mask < 8; mask++ {
+		other, err := EncodeSegmentsWithPolicy(segs, Medium, MinVersion, MaxVersion, mask, SmallestVersion)
+		if err != nil {
+			t.Fatalf("EncodeSegmentsWithPolicy() error = %v", err)
+		}
+		assert.LessOrEqual(t, best, score(other), "mask %d", mask)
+		if mask == qr.GetMask() {
+			assert.Equal(t, other.modules, qr.modules)
+		}
+	}
+
+	_, err = EncodeSegmentsForHalftone(segs, Medium, MinVersion, MaxVersion, SmallestVersion, nil)
+	assert.True(t, errors.Is(err, ErrInvalidArgument))
+}
@@ -0,0 +1,140 @@
+package go_qr
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Indexes of the light and dark colors in the palette of a QrCodeImage.
+const (
+	lightIndex = 0
+	darkIndex  = 1
+)
+
+// QrCodeImage is an image.PalettedImage view of a QR code. Pixels are not stored,
+// but evaluated from the modules of the QR code when they are read.
+// Its palette has the light color at index 0 and the dark color at index 1, followed by the colors
+// of the finder pattern styles.
+type QrCodeImage struct {
+	qr      *QrCode
+	style   *moduleStyle
+	margins Margins // Quiet zone in modules.
+	rect    image.Rectangle
+	palette color.Palette
+}
+
+// Image returns an image of the QR code with the scale, quiet zone and colors of QrCodeImgConfig.
+// The image has the same size as the one written by WriteAsPNG without a frame, and its bounds start at (0, 0).
+func (q *QrCode) Image(config *QrCodeImgConfig) (*QrCodeImage, error) {
+	config, err := q.validateWritePNGConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := config.pngSize(q.size)
+	return q.newImage(config, image.Rect(0, 0, width, height)), nil
+}
+
+// newImage creates a QrCodeImage which stretches the QR code, including its quiet zone, over rect.
+func (q *QrCode) newImage(config *QrCodeImgConfig, rect image.Rectangle) *QrCodeImage {
+	style := q.moduleStyle(config)
+	return &QrCodeImage{
+		qr:      q,
+		style:   style,
+		margins: config.quietZone(),
+		rect:    rect,
+		palette: style.palette,
+	}
+}
+
+// ColorModel returns the palette of the image, or color.NRGBAModel if it has a gradient or module colors,
+// which are not in the palette.
+func (m *QrCodeImage) ColorModel() color.Model {
+	if m.style.shaded() {
+		return color.NRGBAModel
+	}
+	return m.palette
+}
+
+// Bounds returns the bounds of the image.
+func (m *QrCodeImage) Bounds() image.Rectangle {
+	return m.rect
+}
+
+// At returns the color of the pixel at (x, y). With a gradient, dark pixels have the color of the gradient
+// at their center, with module colors, the pixels of a module have its color, and a logo image is drawn over them.
+func (m *QrCodeImage) At(x, y int) color.Color {
+	i := m.ColorIndexAt(x, y)
+	if m.style.shaded() && (image.Point{X: x, Y: y}.In(m.rect)) {
+		// Map the center of the pixel to the scale of the style.
+		scale := float64(m.style.scale)
+		width := float64(m.qr.size + m.margins.Left + m.margins.Right)
+		height := float64(m.qr.size + m.margins.Top + m.margins.Bottom)
+		px := (float64(x-m.rect.Min.X) + 0.5) * width * scale / float64(m.rect.Dx())
+		py := (float64(y-m.rect.Min.Y) + 0.5) * height * scale / float64(m.rect.Dy())
+		moduleX, moduleY, _, _ := m.locate(x, y)
+		if c := m.style.pixelColor(moduleX, moduleY, px, py, i); c != nil {
+			return c
+		}
+	}
+	return m.palette[i]
+}
+
+// ColorIndexAt returns the palette index of the pixel at (x, y): 1 if it belongs to a dark module, 0 for
+// a light one, and the index of its color in a finder pattern drawn with WithFinderStyle.
+func (m *QrCodeImage) ColorIndexAt(x, y int) uint8 {
+	if !(image.Point{X: x, Y: y}.In(m.rect)) {
+		return lightIndex
+	}
+
+	moduleX, moduleY, fx, fy := m.locate(x, y)
+	if m.style.square() {
+		if m.style.qr.GetModule(moduleX, moduleY) {
+			return darkIndex
+		}
+		return lightIndex
+	}
+	return m.style.indexAt(moduleX, moduleY, fx, fy)
+}
+
+// locate maps the pixel at (x, y) inside the image to a module, counting the quiet zone on both sides,
+// and returns the position of the center of the pixel in the module, where fx and fy are between 0 and 1.
+// With square modules, the pixel belongs to the module of its top left corner.
+func (m *QrCodeImage) locate(x, y int) (moduleX, moduleY int, fx, fy float64) {
+	width := int64(m.qr.size + m.margins.Left + m.margins.Right)
+	height := int64(m.qr.size + m.margins.Top + m.margins.Bottom)
+	if m.style.square() {
+		moduleX = int(int64(x-m.rect.Min.X)*width/int64(m.rect.Dx())) - m.margins.Left
+		moduleY = int(int64(y-m.rect.Min.Y)*height/int64(m.rect.Dy())) - m.margins.Top
+		return moduleX, moduleY, 0, 0
+	}
+
+	// Sample the shape at the center of the pixel, computing its position in the module exactly
+	// so that the image matches the PNG output.
+	u, du := (2*int64(x-m.rect.Min.X)+1)*width, 2*int64(m.rect.Dx())
+	v, dv := (2*int64(y-m.rect.Min.Y)+1)*height, 2*int64(m.rect.Dy())
+	fx, fy = float64(u%du)/float64(du), float64(v%dv)/float64(dv)
+	return int(u/du) - m.margins.Left, int(v/dv) - m.margins.Top, fx, fy
+}
+
+// Draw draws the QR code, including its quiet zone, into the rectangle r of dst with the compositing operator op,
+// for example draw.Src to replace the pixels in r or draw.Over to blend a translucent light color.
+// The quiet zone and colors are taken from QrCodeImgConfig. The scale is ignored: the modules are stretched to fill r,
+// so r should be a multiple of the QR code size plus the quiet zone wide and high to get modules of equal size.
+func (q *QrCode) Draw(dst draw.Image, r image.Rectangle, config *QrCodeImgConfig, op draw.Op) error {
+	config, err := q.validateWritePNGConfig(config)
+	if err != nil {
+		return err
+	}
+	if dst == nil {
+		return fmt.Errorf("%w: destination image is nil", ErrInvalidArgument)
+	}
+	if r.Empty() {
+		return fmt.Errorf("%w: empty rectangle %v", ErrInvalidArgument, r)
+	}
+
+	draw.Draw(dst, r, q.newImage(config, r), r.Min, op)
+	return nil
+}
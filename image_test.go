@@ -0,0 +1,108 @@
+package go_qr
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQrCode_Image(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config *QrCodeImgConfig
+	}{
+		{"scale 1 without border", NewQrCodeImgConfig(1, 0)},
+		{"scale 3 with border", NewQrCodeImgConfig(3, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.SetDark(color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xFF})
+
+			img, err := qr.Image(tt.config)
+			if err != nil {
+				t.Fatalf("Image() error = %v", err)
+			}
+			var _ image.PalettedImage = img
+
+			want := qr.toImage(tt.config)
+			assert.Equal(t, want.Bounds(), img.Bounds())
+			assert.Equal(t, color.Palette{tt.config.Light(), tt.config.Dark()}, img.ColorModel())
+			for y := 0; y < want.Bounds().Dy(); y++ {
+				for x := 0; x < want.Bounds().Dx(); x++ {
+					r1, g1, b1, a1 := want.At(x, y).RGBA()
+					r2, g2, b2, a2 := img.At(x, y).RGBA()
+					if [4]uint32{r1, g1, b1, a1} != [4]uint32{r2, g2, b2, a2} {
+						t.Fatalf("At(%d, %d) = %v, want %v", x, y, img.At(x, y), want.At(x, y))
+					}
+				}
+			}
+			assert.Equal(t, uint8(0), img.ColorIndexAt(-1, -1))
+		})
+	}
+
+	_, err = qr.Image(NewQrCodeImgConfig(0, 4))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestQrCode_Draw(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	size := qr.GetSize() + 2*2
+	background := color.RGBA{R: 0xFF, A: 0xFF}
+
+	newDst := func() *image.RGBA {
+		dst := image.NewRGBA(image.Rect(0, 0, 200, 200))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+		return dst
+	}
+
+	t.Run("src", func(t *testing.T) {
+		dst := newDst()
+		r := image.Rect(10, 20, 10+size*2, 20+size*2)
+		err := qr.Draw(dst, r, NewQrCodeImgConfig(1, 2), draw.Src)
+		assert.NoError(t, err)
+
+		assert.Equal(t, background, dst.RGBAAt(9, 20))
+		assert.Equal(t, background, dst.RGBAAt(r.Max.X, r.Max.Y-1))
+		assert.Equal(t, color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}, dst.RGBAAt(10, 20))
+		for y := 0; y < qr.GetSize(); y++ {
+			for x := 0; x < qr.GetSize(); x++ {
+				want := color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+				if qr.GetModule(x, y) {
+					want = color.RGBA{A: 0xFF}
+				}
+				for _, p := range []image.Point{{0, 0}, {1, 1}} {
+					assert.Equal(t, want, dst.RGBAAt(r.Min.X+(x+2)*2+p.X, r.Min.Y+(y+2)*2+p.Y))
+				}
+			}
+		}
+	})
+
+	t.Run("over with transparent light color", func(t *testing.T) {
+		dst := newDst()
+		config := NewQrCodeImgConfig(1, 2)
+		config.SetLight(color.Transparent)
+		r := image.Rect(0, 0, size, size)
+		err := qr.Draw(dst, r, config, draw.Over)
+		assert.NoError(t, err)
+
+		assert.Equal(t, background, dst.RGBAAt(0, 0))
+		assert.Equal(t, color.RGBA{A: 0xFF}, dst.RGBAAt(2, 2))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		assert.ErrorIs(t, qr.Draw(newDst(), image.Rect(5, 5, 5, 10), NewQrCodeImgConfig(1, 2), draw.Src), ErrInvalidArgument)
+		assert.ErrorIs(t, qr.Draw(nil, image.Rect(0, 0, 10, 10), NewQrCodeImgConfig(1, 2), draw.Src), ErrInvalidArgument)
+		assert.ErrorIs(t, qr.Draw(newDst(), image.Rect(0, 0, 10, 10), NewQrCodeImgConfig(1, -1), draw.Src), ErrInvalidConfig)
+	})
+}
@@ -0,0 +1,305 @@
+package go_qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// DefaultLogoErrorBudget is the fraction of the errors each error correction block can correct which
+// the modules cleared for a logo may use, unless Logo.ErrorBudget is set. The rest is kept for damage
+// and misreads of the printed QR code.
+const DefaultLogoErrorBudget = 0.5
+
+// Logo is an image drawn in the middle of a QR code, over an area of light modules.
+type Logo struct {
+	Image      image.Image // Image drawn in PNG output and images, and in SVG output without SVG. It is scaled to fit.
+	SVG        string      // SVG fragment drawn in SVG output, inserted as is in a nested svg element.
+	SVGViewBox string      // Coordinate system of SVG, as the value of a viewBox attribute, or empty for none.
+
+	// Width and height of the cleared area as a fraction of the width of the QR code without its quiet zone.
+	// It is rounded to a whole number of modules, centered on the module grid.
+	Size float64
+	// Light space in modules between the edge of the cleared area and the logo.
+	Padding float64
+	// Fraction of the errors each error correction block can correct which the cleared modules may use,
+	// or 0 for DefaultLogoErrorBudget.
+	ErrorBudget float64
+}
+
+// WithLogo returns a function that draws the logo in the middle of the QR code in PNG and SVG output
+// in the provided QrCodeImgConfig, over modules which are cleared to light. Writing fails with an error
+// matching ErrLogoTooLarge if the logo covers a finder, timing, format or version pattern, or more data
+// than its error budget in any error correction block. FitLogo encodes a QR code in which the logo fits.
+func WithLogo(logo Logo) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.logo = &logo
+	}
+}
+
+// validLogo checks the logo of the config, if any.
+func (q *QrCodeImgConfig) validLogo() error {
+	if q.options.logo == nil {
+		return nil
+	}
+	return q.options.logo.valid()
+}
+
+// valid checks the image, size, padding and error budget of the logo.
+func (logo *Logo) valid() error {
+	switch {
+	case logo.Image == nil && logo.SVG == "":
+		return &ConfigError{Field: "logo", Msg: "logo needs an image or an SVG fragment"}
+	case !(logo.Size > 0 && logo.Size < 1):
+		return &ConfigError{Field: "logo", Msg: "logo size must be between 0 and 1"}
+	case !(logo.Padding >= 0 && !math.IsInf(logo.Padding, 1)):
+		return &ConfigError{Field: "logo", Msg: "logo padding must be non-negative"}
+	case !(logo.ErrorBudget >= 0 && logo.ErrorBudget <= 1):
+		return &ConfigError{Field: "logo", Msg: "logo error budget must be between 0 and 1"}
+	}
+	return nil
+}
+
+// FitLogo returns an EncodePolicy which chooses the smallest version, and in it the lowest error correction level
+// from ecl, in which the segments fit and the logo does not exceed its error budget. At each version, the error
+// correction level is raised before trying a larger version.
+func FitLogo(logo Logo) EncodePolicy {
+	return func(segs []*QrSegment, ecl Ecc, minVer, maxVer int) (int, Ecc, error) {
+		if err := logo.valid(); err != nil {
+			return 0, ecl, err
+		}
+		version, _, err := findSmallestVersion(segs, ecl, minVer, maxVer)
+		if err != nil {
+			return 0, ecl, err
+		}
+
+		var lastErr error
+		for ver := version; ver <= maxVer; ver++ {
+			for newEcl := ecl; newEcl <= High; newEcl++ {
+				if _, err := checkFits(segs, ver, newEcl); err != nil {
+					break
+				}
+				if lastErr = checkLogo(ver, newEcl, logo); lastErr == nil {
+					return ver, newEcl, nil
+				}
+			}
+		}
+		if lastErr == nil {
+			lastErr = &ConfigError{Field: "logo", Msg: "logo does not fit in any version", Err: ErrLogoTooLarge}
+		}
+		return 0, ecl, lastErr
+	}
+}
+
+// CheckLogo checks that the logo only covers data and alignment modules of the QR code, and that it damages
+// no more codewords of any error correction block than its error budget allows. The error matches
+// ErrInvalidConfig, and ErrLogoTooLarge if the logo does not fit.
+func (q *QrCode) CheckLogo(logo Logo) error {
+	if err := logo.valid(); err != nil {
+		return err
+	}
+	return checkLogo(q.version, q.errorCorrectionLevel, logo)
+}
+
+// checkConfigLogo checks that the logo of QrCodeImgConfig, if any, fits in the QR code.
+func (q *QrCode) checkConfigLogo(config *QrCodeImgConfig) error {
+	if config.options.logo == nil {
+		return nil
+	}
+	return checkLogo(q.version, q.errorCorrectionLevel, *config.options.logo)
+}
+
+// checkLogo checks that the logo fits in a QR code of the given version and error correction level.
+func checkLogo(ver int, ecl Ecc, logo Logo) error {
+	blank := &QrCode{version: ver, size: ver*4 + 17, errorCorrectionLevel: ecl}
+	start, n := logoArea(blank.size, logo)
+	if float64(n)-2*logo.Padding <= 0 {
+		return &ConfigError{Field: "logo", Msg: fmt.Sprintf("logo padding leaves no room in %d modules", n), Err: ErrLogoTooLarge}
+	}
+
+	layout := blank.functionLayout()
+	codewords := blank.CodewordMap()
+	damaged := make([]map[int]bool, codewords.NumBlocks())
+	for i := range damaged {
+		damaged[i] = make(map[int]bool)
+	}
+	for y := start; y < start+n; y++ {
+		for x := start; x < start+n; x++ {
+			if role := layout.roles[y][x]; layout.isFunction[y][x] && role != RoleAlignment {
+				return &ConfigError{Field: "logo", Msg: fmt.Sprintf("logo covers the %s pattern of version %d", role, ver), Err: ErrLogoTooLarge}
+			}
+			if cw, ok := codewords.At(x, y); ok {
+				damaged[cw.Block][cw.Index] = true
+			}
+		}
+	}
+
+	budget := logo.ErrorBudget
+	if budget == 0 {
+		budget = DefaultLogoErrorBudget
+	}
+	allowed := budget * float64(correctableErrors(ver, ecl))
+	for block, codewords := range damaged {
+		if float64(len(codewords)) > allowed {
+			return &ConfigError{Field: "logo", Err: ErrLogoTooLarge, Msg: fmt.Sprintf(
+				"logo damages %d codewords of block %d at version %d and error correction level %s, more than the %.1f allowed",
+				len(codewords), block, ver, ecl, allowed)}
+		}
+	}
+	return nil
+}
+
+// correctableErrors returns the number of codeword errors which each error correction block of a QR code
+// of the given version and error correction level can correct. The smallest symbols keep some error
+// correction codewords to detect misdecodes.
+func correctableErrors(ver int, ecl Ecc) int {
+	misdecodeProtection := 0
+	switch {
+	case ver == 1 && ecl == Low:
+		misdecodeProtection = 3
+	case ver == 1 && ecl == Medium, ver == 2 && ecl == Low:
+		misdecodeProtection = 2
+	case ver == 1, ver == 3 && ecl == Low:
+		misdecodeProtection = 1
+	}
+	return (int(getEccCodeWordsPerBlock()[ecl][ver]) - misdecodeProtection) / 2
+}
+
+// logoArea returns the first row and column, and the width in modules of the area cleared for the logo
+// in a QR code of the given size, which has the same parity as the size to be centered.
+func logoArea(size int, logo Logo) (start, n int) {
+	n = int(math.Round(logo.Size * float64(size)))
+	if n%2 != size%2 {
+		n++
+	}
+	if n > size {
+		n = size
+	}
+	return (size - n) / 2, n
+}
+
+// withLogoCleared returns the QR code with the modules under the logo of QrCodeImgConfig cleared to light,
+// or the QR code itself without a logo.
+func (q *QrCode) withLogoCleared(config *QrCodeImgConfig) *QrCode {
+	if config.options.logo == nil {
+		return q
+	}
+	cleared := *q
+	cleared.modules = make([][]bool, q.size)
+	start, n := logoArea(q.size, *config.options.logo)
+	for y := range cleared.modules {
+		cleared.modules[y] = append([]bool(nil), q.modules[y]...)
+		for x := start; y >= start && y < start+n && x < start+n; x++ {
+			cleared.modules[y][x] = false
+		}
+	}
+	return &cleared
+}
+
+// logoRect returns the area inside the padding of the logo of the style, in pixels of an image
+// with the scale and quiet zone of the style.
+func (s *moduleStyle) logoRect() (x, y, w float64) {
+	start, n := logoArea(s.qr.size, *s.logo)
+	scale := float64(s.scale)
+	x = (float64(s.margins.Left+start) + s.logo.Padding) * scale
+	y = (float64(s.margins.Top+start) + s.logo.Padding) * scale
+	return x, y, (float64(n) - 2*s.logo.Padding) * scale
+}
+
+// logoColor returns the color of the logo image of the style in the pixel whose center is at (px, py)
+// in pixels of an image with the scale and quiet zone of the style, averaged over 4x4 samples,
+// and false if the logo does not cover the pixel.
+func (s *moduleStyle) logoColor(px, py float64) (color.Color, bool) {
+	if s.logo == nil || s.logo.Image == nil {
+		return nil, false
+	}
+	bounds := s.logo.Image.Bounds()
+	if bounds.Empty() {
+		return nil, false
+	}
+
+	// The image is scaled to fit the area, keeping its aspect ratio, and centered.
+	x, y, w := s.logoRect()
+	f := math.Min(w/float64(bounds.Dx()), w/float64(bounds.Dy()))
+	x += (w - f*float64(bounds.Dx())) / 2
+	y += (w - f*float64(bounds.Dy())) / 2
+	if px+0.5 <= x || py+0.5 <= y || px-0.5 >= x+f*float64(bounds.Dx()) || py-0.5 >= y+f*float64(bounds.Dy()) {
+		return nil, false
+	}
+
+	const samples = 4
+	var r, g, b, a uint32
+	for i := 0; i < samples; i++ {
+		for j := 0; j < samples; j++ {
+			sx := math.Floor((px - 0.5 + (float64(j)+0.5)/samples - x) / f)
+			sy := math.Floor((py - 0.5 + (float64(i)+0.5)/samples - y) / f)
+			p := image.Point{X: bounds.Min.X + int(sx), Y: bounds.Min.Y + int(sy)}
+			if sx < 0 || sy < 0 || !p.In(bounds) {
+				continue
+			}
+			sr, sg, sb, sa := s.logo.Image.At(p.X, p.Y).RGBA()
+			r, g, b, a = r+sr, g+sg, b+sb, a+sa
+		}
+	}
+	n := uint32(samples * samples)
+	return color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)}, true
+}
+
+// drawOver returns the premultiplied color c drawn over the color background.
+func drawOver(c, background color.Color) color.Color {
+	r, g, b, a := c.RGBA()
+	br, bg, bb, ba := background.RGBA()
+	blend := func(v, bv uint32) uint16 {
+		return uint16(v + bv*(0xFFFF-a)/0xFFFF)
+	}
+	return color.RGBA64{R: blend(r, br), G: blend(g, bg), B: blend(b, bb), A: blend(a, ba)}
+}
+
+// writeLogoSVG writes the logo of the style with margins in pixels: the SVG fragment in a nested svg element,
+// or else the image encoded as PNG.
+func (s *moduleStyle) writeLogoSVG(sw *svgWriter, margins Margins) error {
+	if s.logo == nil {
+		return nil
+	}
+	start, n := logoArea(s.qr.size, *s.logo)
+	scale := float64(s.scale)
+	x := float64(margins.Left) + (float64(start)+s.logo.Padding)*scale
+	y := float64(margins.Top) + (float64(start)+s.logo.Padding)*scale
+	w := (float64(n) - 2*s.logo.Padding) * scale
+	box := func() {
+		sw.writeString(" x=\"")
+		sw.writeFloat(x)
+		sw.writeString("\" y=\"")
+		sw.writeFloat(y)
+		sw.writeString("\" width=\"")
+		sw.writeFloat(w)
+		sw.writeString("\" height=\"")
+		sw.writeFloat(w)
+		sw.writeString("\" preserveAspectRatio=\"xMidYMid meet\"")
+	}
+
+	switch {
+	case s.logo.SVG != "":
+		sw.writeString("\t<svg")
+		box()
+		if s.logo.SVGViewBox != "" {
+			sw.writeString(" viewBox=\"" + svgText(s.logo.SVGViewBox) + "\"")
+		}
+		sw.writeString(">" + s.logo.SVG + "</svg>\n")
+	case s.logo.Image != nil:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, s.logo.Image); err != nil {
+			return fmt.Errorf("failed to encode logo: %w", err)
+		}
+		sw.writeString("\t<image")
+		box()
+		sw.writeString(" xmlns:xlink=\"http://www.w3.org/1999/xlink\" xlink:href=\"data:image/png;base64,")
+		sw.writeString(base64.StdEncoding.EncodeToString(buf.Bytes()))
+		sw.writeString("\"/>\n")
+	}
+	return nil
+}
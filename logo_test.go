@@ -0,0 +1,245 @@
+package go_qr
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLogo returns an opaque red image of the given size.
+func testLogo(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []byte{0xFF, 0, 0, 0xFF})
+	}
+	return img
+}
+
+func TestQrCodeImgConfig_validLogo(t *testing.T) {
+	tests := []struct {
+		name    string
+		logo    Logo
+		wantErr bool
+	}{
+		{"image", Logo{Image: testLogo(4, 4), Size: 0.2}, false},
+		{"svg", Logo{SVG: `<circle r="1"/>`, Size: 0.2, Padding: 1, ErrorBudget: 1}, false},
+		{"no image", Logo{Size: 0.2}, true},
+		{"no size", Logo{Image: testLogo(4, 4)}, true},
+		{"whole code", Logo{Image: testLogo(4, 4), Size: 1}, true},
+		{"negative padding", Logo{Image: testLogo(4, 4), Size: 0.2, Padding: -1}, true},
+		{"NaN padding", Logo{Image: testLogo(4, 4), Size: 0.2, Padding: math.NaN()}, true},
+		{"budget above 1", Logo{Image: testLogo(4, 4), Size: 0.2, ErrorBudget: 1.5}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewQrCodeImgConfig(10, 4, WithLogo(tt.logo)).Valid()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalidConfig))
+			}
+		})
+	}
+}
+
+func TestQrCode_CheckLogo(t *testing.T) {
+	tests := []struct {
+		name    string
+		ver     int
+		ecl     Ecc
+		logo    Logo
+		wantErr bool
+	}{
+		{"small on high", 5, High, Logo{SVG: "<g/>", Size: 0.15}, false},
+		{"large on low", 5, Low, Logo{SVG: "<g/>", Size: 0.3}, true},
+		{"large on high", 10, High, Logo{SVG: "<g/>", Size: 0.2}, false},
+		{"covers timing", 10, High, Logo{SVG: "<g/>", Size: 0.9, ErrorBudget: 1}, true},
+		{"version 1 low", 1, Low, Logo{SVG: "<g/>", Size: 0.1}, true},
+		{"padding fills the area", 5, High, Logo{SVG: "<g/>", Size: 0.1, Padding: 2.5}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segs, err := MakeSegments("HELLO WORLD")
+			if err != nil {
+				t.Fatalf("MakeSegments() error = %v", err)
+			}
+			qr, err := EncodeSegmentsWithPolicy(segs, tt.ecl, tt.ver, tt.ver, -1, SmallestVersion)
+			if err != nil {
+				t.Fatalf("EncodeSegmentsWithPolicy() error = %v", err)
+			}
+			err = qr.CheckLogo(tt.logo)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalidConfig))
+				assert.True(t, errors.Is(err, ErrLogoTooLarge))
+			}
+		})
+	}
+
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	err = qr.CheckLogo(Logo{SVG: "<g/>"})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	assert.False(t, errors.Is(err, ErrLogoTooLarge))
+}
+
+func TestCheckLogo_Budget(t *testing.T) {
+	// The area covers 5x5 modules of version 5, and no block may lose more codewords than the budget allows.
+	logo := Logo{SVG: "<g/>", Size: 5.0 / 37}
+	for ecl := Low; ecl <= High; ecl++ {
+		blank := &QrCode{version: 5, size: 37, errorCorrectionLevel: ecl}
+		codewords := blank.CodewordMap()
+		damaged := make([]map[int]bool, codewords.NumBlocks())
+		for i := range damaged {
+			damaged[i] = map[int]bool{}
+		}
+		for y := 16; y < 21; y++ {
+			for x := 16; x < 21; x++ {
+				if cw, ok := codewords.At(x, y); ok {
+					damaged[cw.Block][cw.Index] = true
+				}
+			}
+		}
+		worst := 0
+		for _, d := range damaged {
+			worst = max(worst, len(d))
+		}
+		err := checkLogo(5, ecl, logo)
+		allowed := DefaultLogoErrorBudget * float64(correctableErrors(5, ecl))
+		assert.Equal(t, float64(worst) > allowed, err != nil, "%s: %v", ecl, err)
+	}
+}
+
+func TestCorrectableErrors(t *testing.T) {
+	assert.Equal(t, 2, correctableErrors(1, Low))
+	assert.Equal(t, 4, correctableErrors(1, Medium))
+	assert.Equal(t, 8, correctableErrors(1, High))
+	assert.Equal(t, 4, correctableErrors(2, Low))
+	assert.Equal(t, 7, correctableErrors(3, Low))
+	assert.Equal(t, 15, correctableErrors(40, High))
+}
+
+func TestFitLogo(t *testing.T) {
+	logo := Logo{SVG: "<g/>", Size: 0.2}
+	segs, err := MakeSegments("https://github.com/piglig/go-qr")
+	if err != nil {
+		t.Fatalf("MakeSegments() error = %v", err)
+	}
+	qr, err := EncodeSegmentsWithPolicy(segs, Low, MinVersion, MaxVersion, -1, FitLogo(logo))
+	if err != nil {
+		t.Fatalf("EncodeSegmentsWithPolicy() error = %v", err)
+	}
+	assert.NoError(t, qr.CheckLogo(logo))
+	assert.Greater(t, qr.GetErrorCorrectionLevel(), Low)
+
+	_, err = EncodeSegmentsWithPolicy(segs, Low, 3, 3, -1, FitLogo(Logo{SVG: "<g/>", Size: 0.6}))
+	assert.True(t, errors.Is(err, ErrLogoTooLarge))
+	_, err = EncodeSegmentsWithPolicy(segs, Low, MinVersion, MaxVersion, -1, FitLogo(Logo{SVG: "<g/>"}))
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
+
+func TestQrCode_WriteAsPNG_Logo(t *testing.T) {
+	qr, err := EncodeText("https://github.com/piglig/go-qr", High)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	const scale, border = 4, 2
+	logo := Logo{Image: testLogo(20, 10), Size: 0.2, Padding: 1}
+	start, n := logoArea(qr.GetSize(), logo)
+	for _, shape := range []ModuleShape{ShapeSquare, ShapeCircle} {
+		t.Run(shape.String(), func(t *testing.T) {
+			config := NewQrCodeImgConfig(scale, border, WithLogo(logo), WithModuleShape(shape))
+			var buf bytes.Buffer
+			assert.NoError(t, qr.WriteAsPNG(config, &buf))
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+
+			red := color.NRGBA{R: 0xFF, A: 0xFF}
+			white := color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+			center := (border+start)*scale + n*scale/2
+			assert.Equal(t, red, color.NRGBAModel.Convert(img.At(center, center)))
+			// The logo is twice as wide as high, so the padding and the area above it are light.
+			for y := start; y < start+n; y++ {
+				for x := start; x < start+n; x++ {
+					px, py := (border+x)*scale+scale/2, (border+y)*scale+scale/2
+					if c := color.NRGBAModel.Convert(img.At(px, py)); c != white && c != red {
+						t.Fatalf("module (%d, %d) under the logo is %v", x, y, c)
+					}
+				}
+			}
+			assert.Equal(t, white, color.NRGBAModel.Convert(img.At(center, (border+start)*scale+scale*3/2)))
+
+			view, err := qr.Image(config)
+			assert.NoError(t, err)
+			bounds := img.Bounds()
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					if color.NRGBAModel.Convert(img.At(x, y)) != color.NRGBAModel.Convert(view.At(x, y)) {
+						t.Fatalf("pixel (%d, %d) of the PNG and the image differ", x, y)
+					}
+				}
+			}
+		})
+	}
+
+	err = qr.WriteAsPNG(NewQrCodeImgConfig(scale, border, WithLogo(logo), WithPNGColorMode(PNGColorPaletted)), &bytes.Buffer{})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	err = qr.WriteAsPNG(NewQrCodeImgConfig(scale, border, WithLogo(Logo{Image: logo.Image, Size: 0.9})), &bytes.Buffer{})
+	assert.True(t, errors.Is(err, ErrLogoTooLarge))
+}
+
+func TestQrCode_SVGString_Logo(t *testing.T) {
+	qr, err := EncodeText("https://github.com/piglig/go-qr", High)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	start, n := logoArea(qr.GetSize(), Logo{Size: 0.2})
+
+	tests := []struct {
+		name     string
+		logo     Logo
+		options  []func(*QrCodeImgConfig)
+		contains string
+	}{
+		{
+			name:     "svg",
+			logo:     Logo{SVG: `<circle cx="1" cy="1" r="1"/>`, SVGViewBox: "0 0 2 2", Size: 0.2, Padding: 0.5},
+			contains: `<svg x="135" y="135" width="60" height="60" preserveAspectRatio="xMidYMid meet" viewBox="0 0 2 2"><circle cx="1" cy="1" r="1"/></svg>`,
+		},
+		{
+			name:     "image",
+			logo:     Logo{Image: testLogo(2, 2), Size: 0.2},
+			options:  []func(*QrCodeImgConfig){WithOptimalSVG()},
+			contains: `<image x="130" y="130" width="70" height="70" preserveAspectRatio="xMidYMid meet" xmlns:xlink="http://www.w3.org/1999/xlink" xlink:href="data:image/png;base64,`,
+		},
+	}
+	paths := regexp.MustCompile(`<path d="([^"]*)"`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg, err := qr.SVGString(NewQrCodeImgConfig(10, 0, append(tt.options, WithLogo(tt.logo))...))
+			assert.NoError(t, err)
+			assert.Contains(t, svg, tt.contains)
+			assert.True(t, strings.HasSuffix(svg, "</svg>\n</svg>\n") || strings.HasSuffix(svg, "\"/>\n</svg>\n"))
+
+			// No dark module is drawn under the logo.
+			loops := pathLoops(t, paths.FindStringSubmatch(svg)[1])
+			for y := start; y < start+n; y++ {
+				for x := start; x < start+n; x++ {
+					assert.False(t, insideEvenOdd(loops, float64(x*10+5), float64(y*10+5)), "module (%d, %d)", x, y)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package go_qr
+
+import "fmt"
+
+// MinQuietZone is the width of the quiet zone in modules which the QR code standard requires around a QR code.
+const MinQuietZone = 4
+
+// Margins is the width of the quiet zone on each side of a QR code, in modules.
+type Margins struct {
+	Top, Right, Bottom, Left int
+}
+
+// WithQuietZone returns a function that sets a quiet zone of the given number of modules on every side
+// in the provided QrCodeImgConfig. Unlike the border, which PNG output counts in modules and SVG output
+// in pixels, the quiet zone is counted in modules by all writers. It replaces the border.
+func WithQuietZone(modules int) func(*QrCodeImgConfig) {
+	return WithMargins(Margins{Top: modules, Right: modules, Bottom: modules, Left: modules})
+}
+
+// WithMargins returns a function that sets a quiet zone with a different number of modules on each side
+// in the provided QrCodeImgConfig, for example to align the QR code with other elements of a layout.
+// Like WithQuietZone, it replaces the border.
+func WithMargins(margins Margins) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.margins = &margins
+	}
+}
+
+// WithStrictQuietZone returns a function that makes Valid, and so all writers, fail in the provided
+// QrCodeImgConfig when the quiet zone is narrower than MinQuietZone modules, instead of only
+// reporting it in Warnings.
+func WithStrictQuietZone() func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.strictQuietZone = true
+	}
+}
+
+// Warnings returns the problems of QrCodeImgConfig which do not prevent writing the QR code, but can make it
+// hard to scan: a quiet zone narrower than MinQuietZone modules on any side, matching ErrQuietZoneTooSmall,
+// and a gradient with colors below MinContrast against the light color, matching ErrLowContrast.
+// Without WithQuietZone, WithMargins or WithPrintSize, the border must be wide enough for both writers:
+// MinQuietZone modules in PNG output, and MinQuietZone modules of pixels in SVG output.
+func (q *QrCodeImgConfig) Warnings() []error {
+	return append(q.quietZoneWarnings(), q.gradientWarnings()...)
+}
+
+// quietZoneWarnings returns a warning matching ErrQuietZoneTooSmall for each side of the quiet zone
+// which is narrower than MinQuietZone modules in any output.
+func (q *QrCodeImgConfig) quietZoneWarnings() []error {
+	var warnings []error
+	if q.options.margins == nil && q.options.printSize == nil {
+		// SVG output counts the border in pixels, so it is the narrower quiet zone for every scale.
+		if minimum := MinQuietZone * q.scale; q.border < minimum {
+			for _, side := range []string{"top", "right", "bottom", "left"} {
+				warnings = append(warnings, fmt.Errorf("%w: %s border of %d pixels in SVG output is less than the minimum of %d modules of %d pixels",
+					ErrQuietZoneTooSmall, side, q.border, MinQuietZone, minimum))
+			}
+		}
+		return warnings
+	}
+
+	m := q.quietZone()
+	for _, side := range []struct {
+		name    string
+		modules int
+	}{{"top", m.Top}, {"right", m.Right}, {"bottom", m.Bottom}, {"left", m.Left}} {
+		if side.modules < MinQuietZone {
+			warnings = append(warnings, fmt.Errorf("%w: %s quiet zone of %d modules is less than the minimum of %d modules",
+				ErrQuietZoneTooSmall, side.name, side.modules, MinQuietZone))
+		}
+	}
+	return warnings
+}
+
+// validMargins checks that the margins are not negative, and that the quiet zone is wide enough in strict mode.
+func (q *QrCodeImgConfig) validMargins() error {
+	if m := q.options.margins; m != nil && (m.Top < 0 || m.Right < 0 || m.Bottom < 0 || m.Left < 0) {
+		return &ConfigError{Field: "margins", Msg: "margins must be non-negative"}
+	}
+
+	if q.options.strictQuietZone {
+		if warnings := q.quietZoneWarnings(); len(warnings) > 0 {
+			return &ConfigError{Field: "quietZone", Msg: warnings[0].Error(), Err: warnings[0]}
+		}
+	}
+	return nil
+}
+
+// quietZone returns the quiet zone in modules: the margins set by WithQuietZone or WithMargins,
+// else the quiet zone of the print size, else the border on every side.
+func (q *QrCodeImgConfig) quietZone() Margins {
+	switch {
+	case q.options.margins != nil:
+		return *q.options.margins
+	case q.options.printSize != nil:
+		n := q.options.printSize.QuietZone
+		return Margins{Top: n, Right: n, Bottom: n, Left: n}
+	}
+	return Margins{Top: q.border, Right: q.border, Bottom: q.border, Left: q.border}
+}
+
+// svgMargins returns the margins of SVG output in pixels. For compatibility, the border is counted in pixels,
+// while the quiet zone set by WithQuietZone, WithMargins or WithPrintSize is counted in modules.
+func (q *QrCodeImgConfig) svgMargins() Margins {
+	if q.options.margins == nil && q.options.printSize == nil {
+		return Margins{Top: q.border, Right: q.border, Bottom: q.border, Left: q.border}
+	}
+	m := q.quietZone()
+	return Margins{Top: m.Top * q.scale, Right: m.Right * q.scale, Bottom: m.Bottom * q.scale, Left: m.Left * q.scale}
+}
+
+// pngSize returns the width and height of a PNG image of a QR code of the given size in modules.
+func (q *QrCodeImgConfig) pngSize(size int) (int, int) {
+	m := q.quietZone()
+	return (size + m.Left + m.Right) * q.scale, (size + m.Top + m.Bottom) * q.scale
+}
+
+// svgSize returns the width and height of an SVG image of a QR code of the given size in modules.
+func (q *QrCodeImgConfig) svgSize(size int) (int, int) {
+	m := q.svgMargins()
+	return size*q.scale + m.Left + m.Right, size*q.scale + m.Top + m.Bottom
+}
@@ -0,0 +1,110 @@
+package go_qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQrCode_QuietZone(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	tests := []struct {
+		name                  string
+		option                func(*QrCodeImgConfig)
+		wantWidth, wantHeight int
+		wantFirstModule       string
+		wantLeft, wantTop     int
+	}{
+		{"symmetric", WithQuietZone(4), (21 + 8) * 3, (21 + 8) * 3, "M12,12h3v3h-3z", 12, 12},
+		{"asymmetric", WithMargins(Margins{Top: 1, Right: 2, Bottom: 3, Left: 4}), (21 + 6) * 3, (21 + 4) * 3, "M12,3h3v3h-3z", 12, 3},
+		{"none", WithQuietZone(0), 21 * 3, 21 * 3, "M0,0h3v3h-3z", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The border is replaced by the quiet zone.
+			config := NewQrCodeImgConfig(3, 10, tt.option)
+
+			var buf bytes.Buffer
+			if err := qr.WriteAsPNG(config, &buf); err != nil {
+				t.Fatalf("WriteAsPNG() error = %v", err)
+			}
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+			assert.Equal(t, tt.wantWidth, img.Bounds().Dx())
+			assert.Equal(t, tt.wantHeight, img.Bounds().Dy())
+			// The top left module is dark, the pixel before it is light.
+			r, _, _, _ := img.At(tt.wantLeft, tt.wantTop).RGBA()
+			assert.Zero(t, r)
+			if tt.wantLeft > 0 {
+				r, _, _, _ = img.At(tt.wantLeft-1, tt.wantTop).RGBA()
+				assert.NotZero(t, r)
+			}
+
+			for _, optimal := range []bool{false, true} {
+				svgConfig := NewQrCodeImgConfig(3, 10, tt.option)
+				if optimal {
+					WithOptimalSVG()(svgConfig)
+				}
+				svg, err := qr.SVGString(svgConfig)
+				assert.NoError(t, err)
+				assert.Contains(t, svg, fmt.Sprintf(`viewBox="0 0 %d %d"`, tt.wantWidth, tt.wantHeight))
+				if !optimal {
+					assert.Contains(t, svg, `<path d="`+tt.wantFirstModule)
+				}
+			}
+		})
+	}
+}
+
+func TestQrCodeImgConfig_Warnings(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *QrCodeImgConfig
+		wantCount int
+	}{
+		{"border in pixels", NewQrCodeImgConfig(10, 40), 0},
+		{"border in modules", NewQrCodeImgConfig(10, 4), 4},
+		{"border at scale 1", NewQrCodeImgConfig(1, 4), 0},
+		{"small border", NewQrCodeImgConfig(1, 2), 4},
+		{"quiet zone", NewQrCodeImgConfig(10, 0, WithQuietZone(4)), 0},
+		{"small margins", NewQrCodeImgConfig(10, 4, WithMargins(Margins{Top: 4, Right: 3, Bottom: 4, Left: 0})), 2},
+		{"print size", NewQrCodeImgConfig(0, 0, WithPrintSize(PrintSize{Width: 30, DPI: 300, QuietZone: 1})), 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := tt.config.Warnings()
+			assert.Len(t, warnings, tt.wantCount)
+			for _, w := range warnings {
+				assert.ErrorIs(t, w, ErrQuietZoneTooSmall)
+			}
+			assert.NoError(t, tt.config.Valid())
+		})
+	}
+
+	strict := NewQrCodeImgConfig(10, 0, WithMargins(Margins{Top: 4, Right: 4, Bottom: 4, Left: 2}), WithStrictQuietZone())
+	err := strict.Valid()
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorIs(t, err, ErrQuietZoneTooSmall)
+	assert.EqualError(t, err, "quiet zone too small: left quiet zone of 2 modules is less than the minimum of 4 modules")
+	assert.NoError(t, NewQrCodeImgConfig(10, 0, WithQuietZone(4), WithStrictQuietZone()).Valid())
+
+	// A border of 4 is 4 modules in PNG output, but less than half a module in SVG output.
+	err = NewQrCodeImgConfig(10, 4, WithStrictQuietZone()).Valid()
+	assert.ErrorIs(t, err, ErrQuietZoneTooSmall)
+	assert.EqualError(t, err, "quiet zone too small: top border of 4 pixels in SVG output is less than the minimum of 4 modules of 40 pixels")
+
+	var configErr *ConfigError
+	err = NewQrCodeImgConfig(10, 4, WithMargins(Margins{Top: -1})).Valid()
+	assert.True(t, errors.As(err, &configErr))
+	assert.Equal(t, "margins", configErr.Field)
+}
@@ -0,0 +1,320 @@
+package go_qr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// binaryMagic starts every QR code encoded by MarshalBinary, followed by the encoding format version.
+const (
+	binaryMagic      = "QR"
+	binaryFormat     = 1
+	binaryHeaderSize = len(binaryMagic) + 4
+)
+
+// Characters used by MarshalText for dark and light modules.
+const (
+	textDark  = '#'
+	textLight = '.'
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler. The QR code is encoded as a 6 byte header
+// ("QR", format 1, version, error correction level, mask), followed by the modules row by row,
+// packed 8 per byte with the first module in the most significant bit.
+func (q *QrCode) MarshalBinary() ([]byte, error) {
+	data := make([]byte, binaryHeaderSize, binaryHeaderSize+(q.size*q.size+7)/8)
+	copy(data, binaryMagic)
+	data[2] = binaryFormat
+	data[3] = byte(q.version)
+	data[4] = byte(q.errorCorrectionLevel)
+	data[5] = byte(q.mask)
+
+	var cur byte
+	n := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			cur <<= 1
+			if q.modules[y][x] {
+				cur |= 1
+			}
+			n++
+			if n%8 == 0 {
+				data = append(data, cur)
+				cur = 0
+			}
+		}
+	}
+	if n%8 != 0 {
+		data = append(data, cur<<uint(8-n%8))
+	}
+	return data, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for data written by MarshalBinary.
+// It returns an error if the format or version information drawn in the modules
+// does not agree with the version, error correction level and mask in the header.
+func (q *QrCode) UnmarshalBinary(data []byte) error {
+	if len(data) < binaryHeaderSize || string(data[:len(binaryMagic)]) != binaryMagic {
+		return fmt.Errorf("%w: not a binary QR code", ErrInvalidArgument)
+	}
+	if data[2] != binaryFormat {
+		return fmt.Errorf("%w: unsupported binary QR code format %d", ErrInvalidArgument, data[2])
+	}
+
+	ver, ecl, msk := int(data[3]), Ecc(data[4]), int(data[5])
+	if err := checkHeader(ver, ecl, msk); err != nil {
+		return err
+	}
+
+	size := ver*4 + 17
+	packed := data[binaryHeaderSize:]
+	if len(packed) != (size*size+7)/8 {
+		return fmt.Errorf("%w: %d bytes of modules for version %d, want %d", ErrInvalidArgument, len(packed), ver, (size*size+7)/8)
+	}
+
+	modules := make([][]bool, size)
+	for y := range modules {
+		modules[y] = make([]bool, size)
+		for x := range modules[y] {
+			i := y*size + x
+			modules[y][x] = packed[i/8]&(0x80>>uint(i%8)) != 0
+		}
+	}
+	return q.load(ver, ecl, msk, modules)
+}
+
+// MarshalText implements encoding.TextMarshaler. The QR code is written as a grid of
+// '#' for dark and '.' for light modules, with one line per row.
+func (q *QrCode) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow((q.size + 1) * q.size)
+	for _, row := range q.textRows() {
+		buf.WriteString(row)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler for a grid written by MarshalText.
+// The version is derived from the size of the grid, and the error correction level and mask
+// from the format information, which must be drawn identically in both copies.
+func (q *QrCode) UnmarshalText(text []byte) error {
+	lines := strings.Split(strings.TrimRight(string(text), "\r\n"), "\n")
+	modules, err := parseTextRows(lines)
+	if err != nil {
+		return err
+	}
+
+	size := len(modules)
+	ver := (size - 17) / 4
+	if ver*4+17 != size || ver < MinVersion || ver > MaxVersion {
+		return fmt.Errorf("%w: no QR code version has size %d", ErrInvalidVersion, size)
+	}
+
+	bits := (&QrCode{size: size, modules: modules}).readFormatBits()
+	if bits == -1 {
+		return fmt.Errorf("%w: the two copies of the format information differ", ErrInvalidArgument)
+	}
+	for ecl := Low; ecl <= High; ecl++ {
+		for msk := 0; msk < 8; msk++ {
+			if formatBits(ecl, msk) == bits {
+				return q.load(ver, ecl, msk, modules)
+			}
+		}
+	}
+	return fmt.Errorf("%w: invalid format information %#04x", ErrInvalidArgument, bits)
+}
+
+// qrCodeJSON is the JSON representation of a QrCode.
+type qrCodeJSON struct {
+	Version              int      `json:"version"`
+	ErrorCorrectionLevel string   `json:"errorCorrectionLevel"`
+	Mask                 int      `json:"mask"`
+	Size                 int      `json:"size"`
+	Modules              []string `json:"modules"`
+}
+
+// MarshalJSON implements json.Marshaler. The QR code is written as an object with its version,
+// error correction level ("L", "M", "Q" or "H"), mask and size, and its modules as an array
+// of rows in the format of MarshalText.
+func (q *QrCode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(qrCodeJSON{
+		Version:              q.version,
+		ErrorCorrectionLevel: q.errorCorrectionLevel.String(),
+		Mask:                 q.mask,
+		Size:                 q.size,
+		Modules:              q.textRows(),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for an object written by MarshalJSON.
+// It returns an error if the size or the format and version information drawn in the modules
+// do not agree with the stated version, error correction level and mask.
+func (q *QrCode) UnmarshalJSON(data []byte) error {
+	var v qrCodeJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	ecl, err := ParseEcc(v.ErrorCorrectionLevel)
+	if err != nil {
+		return err
+	}
+	if err := checkHeader(v.Version, ecl, v.Mask); err != nil {
+		return err
+	}
+
+	modules, err := parseTextRows(v.Modules)
+	if err != nil {
+		return err
+	}
+	if v.Size != len(modules) {
+		return fmt.Errorf("%w: size %d does not match %d rows of modules", ErrInvalidArgument, v.Size, len(modules))
+	}
+	return q.load(v.Version, ecl, v.Mask, modules)
+}
+
+// checkHeader checks the version, error correction level and mask of a serialized QR code.
+func checkHeader(ver int, ecl Ecc, msk int) error {
+	if ver < MinVersion || ver > MaxVersion {
+		return fmt.Errorf("%w: version %d out of range", ErrInvalidVersion, ver)
+	}
+	if ecl < Low || ecl > High {
+		return fmt.Errorf("%w: error correction level %d out of range", ErrInvalidArgument, ecl)
+	}
+	if msk < 0 || msk > 7 {
+		return fmt.Errorf("%w: mask %d out of range", ErrInvalidMask, msk)
+	}
+	return nil
+}
+
+// load sets the QR code to the given modules, after checking that their size and the format and
+// version information drawn in them agree with the version, error correction level and mask.
+func (q *QrCode) load(ver int, ecl Ecc, msk int, modules [][]bool) error {
+	loaded := QrCode{
+		version:              ver,
+		size:                 ver*4 + 17,
+		errorCorrectionLevel: ecl,
+		mask:                 msk,
+		modules:              modules,
+	}
+
+	if len(modules) != loaded.size {
+		return fmt.Errorf("%w: size %d does not match version %d", ErrInvalidVersion, len(modules), ver)
+	}
+	if got, want := loaded.readFormatBits(), loaded.GetFormatBits(); got != want {
+		return fmt.Errorf("%w: format information %#04x does not match error correction level %s and mask %d",
+			ErrInvalidArgument, got, ecl, msk)
+	}
+	if got, want := loaded.readVersionBits(), loaded.GetVersionBits(); got != want {
+		return fmt.Errorf("%w: version information %#05x does not match version %d", ErrInvalidVersion, got, ver)
+	}
+
+	*q = loaded
+	return nil
+}
+
+// readFormatBits reads the format word from the modules. If the two copies differ, -1 is returned.
+func (q *QrCode) readFormatBits() int {
+	first, second := 0, 0
+	for i := 0; i < 15; i++ {
+		// Mirrors the positions used by drawFormatBits.
+		var x, y int
+		switch {
+		case i <= 5:
+			x, y = 8, i
+		case i == 6:
+			x, y = 8, 7
+		case i == 7:
+			x, y = 8, 8
+		case i == 8:
+			x, y = 7, 8
+		default:
+			x, y = 14-i, 8
+		}
+		if q.GetModule(x, y) {
+			first |= 1 << uint(i)
+		}
+
+		if i < 8 {
+			x, y = q.size-1-i, 8
+		} else {
+			x, y = 8, q.size-15+i
+		}
+		if q.GetModule(x, y) {
+			second |= 1 << uint(i)
+		}
+	}
+
+	if first != second {
+		return -1
+	}
+	return first
+}
+
+// readVersionBits reads the version word from the modules, or returns 0 for versions below 7.
+// If the two copies differ, -1 is returned.
+func (q *QrCode) readVersionBits() int {
+	if q.version < 7 {
+		return 0
+	}
+
+	first, second := 0, 0
+	for i := 0; i < 18; i++ {
+		// Mirrors the positions used by drawVersion.
+		a := q.size - 11 + i%3
+		b := i / 3
+		if q.GetModule(a, b) {
+			first |= 1 << uint(i)
+		}
+		if q.GetModule(b, a) {
+			second |= 1 << uint(i)
+		}
+	}
+
+	if first != second {
+		return -1
+	}
+	return first
+}
+
+// textRows returns the rows of modules as strings of '#' and '.'.
+func (q *QrCode) textRows() []string {
+	rows := make([]string, q.size)
+	row := make([]byte, q.size)
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			row[x] = textLight
+			if q.modules[y][x] {
+				row[x] = textDark
+			}
+		}
+		rows[y] = string(row)
+	}
+	return rows
+}
+
+// parseTextRows parses rows of '#' and '.' into a square matrix of modules.
+func parseTextRows(rows []string) ([][]bool, error) {
+	modules := make([][]bool, len(rows))
+	for y, row := range rows {
+		row = strings.TrimSuffix(row, "\r")
+		if len(row) != len(rows) {
+			return nil, fmt.Errorf("%w: row %d has %d modules, want %d", ErrInvalidArgument, y, len(row), len(rows))
+		}
+
+		modules[y] = make([]bool, len(row))
+		for x := 0; x < len(row); x++ {
+			switch row[x] {
+			case textDark:
+				modules[y][x] = true
+			case textLight:
+			default:
+				return nil, fmt.Errorf("%w: invalid module %q at row %d, column %d", ErrInvalidArgument, row[x], y, x)
+			}
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,159 @@
+package go_qr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQrCode_MarshalRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		ecl  Ecc
+	}{
+		{"version 1", "Hello, world!", Low},
+		{"version 7 with version information", strings.Repeat("0123456789", 14), High},
+		{"version 40", strings.Repeat("a", 2953), Low},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := EncodeText(tt.text, tt.ecl)
+			if err != nil {
+				t.Fatalf("EncodeText() error = %v", err)
+			}
+
+			data, err := qr.MarshalBinary()
+			assert.NoError(t, err)
+			assert.Len(t, data, 6+(qr.GetSize()*qr.GetSize()+7)/8)
+			fromBinary := &QrCode{}
+			assert.NoError(t, fromBinary.UnmarshalBinary(data))
+			assert.Equal(t, qr, fromBinary)
+
+			text, err := qr.MarshalText()
+			assert.NoError(t, err)
+			fromText := &QrCode{}
+			assert.NoError(t, fromText.UnmarshalText(text))
+			assert.Equal(t, qr, fromText)
+
+			js, err := json.Marshal(qr)
+			assert.NoError(t, err)
+			fromJSON := &QrCode{}
+			assert.NoError(t, json.Unmarshal(js, fromJSON))
+			assert.Equal(t, qr, fromJSON)
+		})
+	}
+}
+
+func TestQrCode_MarshalText(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	text, err := qr.MarshalText()
+	assert.NoError(t, err)
+	lines := strings.Split(string(text), "\n")
+	assert.Len(t, lines, qr.GetSize()+1)
+	assert.Equal(t, "#######.", lines[0][:8])
+	assert.Equal(t, "#.....#.", lines[1][:8])
+	assert.Equal(t, "", lines[qr.GetSize()])
+
+	js, err := json.Marshal(qr)
+	assert.NoError(t, err)
+	assert.Contains(t, string(js), `{"version":1,"errorCorrectionLevel":"M","mask":2,`)
+	assert.Contains(t, string(js), `"size":21,"modules":["#######.`)
+}
+
+func TestQrCode_UnmarshalErrors(t *testing.T) {
+	qr, err := EncodeText(strings.Repeat("0123456789", 14), High)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	data, _ := qr.MarshalBinary()
+	text, _ := qr.MarshalText()
+
+	flip := func(x, y int) []byte {
+		flipped := append([]byte(nil), text...)
+		i := y*(qr.GetSize()+1) + x
+		if flipped[i] == '#' {
+			flipped[i] = '.'
+		} else {
+			flipped[i] = '#'
+		}
+		return flipped
+	}
+
+	t.Run("binary", func(t *testing.T) {
+		withHeader := func(ver, ecl, msk byte) []byte {
+			changed := append([]byte(nil), data...)
+			changed[3], changed[4], changed[5] = ver, ecl, msk
+			return changed
+		}
+
+		tests := []struct {
+			name    string
+			data    []byte
+			wantErr error
+		}{
+			{"empty", nil, ErrInvalidArgument},
+			{"bad magic", append([]byte("XX"), data[2:]...), ErrInvalidArgument},
+			{"bad format", append([]byte("QR\x02"), data[3:]...), ErrInvalidArgument},
+			{"truncated", data[:len(data)-1], ErrInvalidArgument},
+			{"version out of range", withHeader(41, data[4], data[5]), ErrInvalidVersion},
+			{"mask out of range", withHeader(data[3], data[4], 8), ErrInvalidMask},
+			{"wrong mask", withHeader(data[3], data[4], (data[5]+1)%8), ErrInvalidArgument},
+			{"wrong error correction level", withHeader(data[3], byte(Low), data[5]), ErrInvalidArgument},
+			{"wrong version", withHeader(data[3]+1, data[4], data[5]), ErrInvalidArgument},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got := &QrCode{}
+				assert.ErrorIs(t, got.UnmarshalBinary(tt.data), tt.wantErr)
+				assert.Equal(t, &QrCode{}, got)
+			})
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			text    []byte
+			wantErr error
+		}{
+			{"not square", text[:len(text)-qr.GetSize()-1], ErrInvalidArgument},
+			{"invalid character", []byte(strings.Replace(string(text), "#", "X", 1)), ErrInvalidArgument},
+			{"invalid size", []byte("#\n"), ErrInvalidVersion},
+			{"format copies differ", flip(8, 0), ErrInvalidArgument},
+			{"version copies differ", flip(qr.GetSize()-11, 0), ErrInvalidVersion},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				assert.ErrorIs(t, (&QrCode{}).UnmarshalText(tt.text), tt.wantErr)
+			})
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			replace [2]string
+			wantErr error
+		}{
+			{"wrong version", [2]string{`"version":7`, `"version":8`}, ErrInvalidVersion},
+			{"wrong size", [2]string{`"size":45`, `"size":44`}, ErrInvalidArgument},
+			{"wrong error correction level", [2]string{`"errorCorrectionLevel":"H"`, `"errorCorrectionLevel":"L"`}, ErrInvalidArgument},
+			{"unknown error correction level", [2]string{`"errorCorrectionLevel":"H"`, `"errorCorrectionLevel":"X"`}, ErrInvalidArgument},
+		}
+		js, _ := json.Marshal(qr)
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				changed := strings.Replace(string(js), tt.replace[0], tt.replace[1], 1)
+				assert.NotEqual(t, string(js), changed)
+				assert.ErrorIs(t, json.Unmarshal([]byte(changed), &QrCode{}), tt.wantErr)
+			})
+		}
+	})
+}
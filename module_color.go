@@ -0,0 +1,135 @@
+package go_qr
+
+import "image/color"
+
+// ModuleColorFunc returns the color of the module at (x, y), which is dark or light and plays role in the QR code,
+// or nil to keep the light color, or the gradient or dark color.
+type ModuleColorFunc func(x, y int, dark bool, role ModuleRole) color.Color
+
+// WithModuleColorFunc returns a function that colors each module of the QR code with f in PNG and SVG output
+// in the provided QrCodeImgConfig, for example to color the data area and keep the function patterns black.
+// The quiet zone keeps the light color, and the finder patterns drawn with WithFinderStyle keep their style.
+// With a module shape, the area of a dark module outside its shape keeps the light color, and bars only join
+// modules of the same color. SVG output draws the modules of each color as one path.
+func WithModuleColorFunc(f ModuleColorFunc) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.moduleColorFunc = f
+	}
+}
+
+// initModuleColors calls f for each module which is not part of a styled finder pattern, and keeps the distinct
+// colors it returns in the style, with the index of the color of each module.
+func (s *moduleStyle) initModuleColors(f ModuleColorFunc) {
+	roles := s.qr.ModuleRoles()
+	s.colors = []color.Color{nil}
+	s.colorKeys = make([][]int, s.qr.size)
+	type rgba struct{ r, g, b, a uint32 }
+	keys := make(map[rgba]int)
+	for y := range s.colorKeys {
+		s.colorKeys[y] = make([]int, s.qr.size)
+		for x := range s.colorKeys[y] {
+			if s.finderStyled(x, y) {
+				continue
+			}
+			c := f(x, y, s.qr.GetModule(x, y), roles[y][x])
+			if c == nil {
+				continue
+			}
+			var k rgba
+			k.r, k.g, k.b, k.a = c.RGBA()
+			key, ok := keys[k]
+			if !ok {
+				key = len(s.colors)
+				keys[k] = key
+				s.colors = append(s.colors, c)
+			}
+			s.colorKeys[y][x] = key
+		}
+	}
+}
+
+// colorKey returns the index in the colors of the style of the color of the module at (x, y),
+// or 0 if it has the default color.
+func (s *moduleStyle) colorKey(x, y int) int {
+	if s.colorKeys == nil || x < 0 || y < 0 || x >= s.qr.size || y >= s.qr.size {
+		return 0
+	}
+	return s.colorKeys[y][x]
+}
+
+// shaded reports whether pixels can have colors which are not in the palette of the style.
+func (s *moduleStyle) shaded() bool {
+	return s.gradient != nil || s.colorKeys != nil || s.logo != nil && s.logo.Image != nil
+}
+
+// pixelColor returns the color of a pixel with palette index i in the module at (x, y), whose center is at
+// (px, py) in pixels of an image with the scale and quiet zone of the style, or nil if it has the color
+// of the palette. Light pixels of dark modules keep the light color. The logo image is drawn over the pixels it covers.
+func (s *moduleStyle) pixelColor(x, y int, px, py float64, i uint8) color.Color {
+	c := s.moduleColor(x, y, px, py, i)
+	if logo, ok := s.logoColor(px, py); ok {
+		if c == nil {
+			c = s.palette[i]
+		}
+		return drawOver(logo, c)
+	}
+	return c
+}
+
+// moduleColor returns the color of a pixel as pixelColor does, without the logo.
+func (s *moduleStyle) moduleColor(x, y int, px, py float64, i uint8) color.Color {
+	if key := s.colorKey(x, y); key != 0 && (i == darkIndex) == s.qr.GetModule(x, y) {
+		return s.colors[key]
+	}
+	if i == darkIndex && s.gradient != nil {
+		return s.gradientColor(px, py)
+	}
+	return nil
+}
+
+// colorGroups returns the keys of the colors of the dark or light modules which are not part of a styled
+// finder pattern, in the order in which they first appear. The default color comes first for dark modules,
+// and is left out for light ones.
+func (s *moduleStyle) colorGroups(dark bool) []int {
+	groups := []int{}
+	if dark {
+		groups = append(groups, 0)
+	}
+	seen := map[int]bool{0: true}
+	for y := 0; y < s.qr.size && s.colorKeys != nil; y++ {
+		for x := 0; x < s.qr.size; x++ {
+			if key := s.colorKeys[y][x]; !seen[key] && s.qr.GetModule(x, y) == dark {
+				seen[key] = true
+				groups = append(groups, key)
+			}
+		}
+	}
+	return groups
+}
+
+// inGroup returns a function which reports whether a module is dark or light, is not part of a styled finder
+// pattern and has the color with the key.
+func (s *moduleStyle) inGroup(dark bool, key int) func(x, y int) bool {
+	return func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < s.qr.size && y < s.qr.size && s.qr.GetModule(x, y) == dark &&
+			!s.finderStyled(x, y) && s.colorKey(x, y) == key
+	}
+}
+
+// groupPaint returns the paint of the modules with the color key, which is dark for the default color.
+func (s *moduleStyle) groupPaint(key int, dark svgPaint) svgPaint {
+	if key == 0 {
+		return dark
+	}
+	return newSVGPaint(s.colors[key])
+}
+
+// writeLightModulesSVG writes a path of squares for the light modules of each color of the style,
+// with margins in pixels.
+func (s *moduleStyle) writeLightModulesSVG(sw *svgWriter, margins Margins) {
+	for _, key := range s.colorGroups(false) {
+		sw.writeString("\t<path d=\"")
+		writeLoops(sw, margins, s.scale, s.qr.borderLoops(s.inGroup(false, key)))
+		sw.writeString("\" fill-rule=\"evenodd\"" + s.groupPaint(key, svgPaint{}).attrs() + "/>\n")
+	}
+}
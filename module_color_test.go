@@ -0,0 +1,170 @@
+package go_qr
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testYellow = color.RGBA{R: 0xFF, G: 0xFF, A: 0xFF}
+	testWhite  = color.RGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	testBlack  = color.RGBA{A: 0xFF}
+)
+
+// colorDataArea colors the dark data and error correction modules red and the light ones yellow,
+// and keeps the default colors of the function patterns.
+func colorDataArea(x, y int, dark bool, role ModuleRole) color.Color {
+	switch {
+	case role.IsFunction():
+		return nil
+	case dark:
+		return testRed
+	}
+	return testYellow
+}
+
+func TestWithModuleColorFunc_Roles(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	roles := qr.ModuleRoles()
+	calls := 0
+	config := NewQrCodeImgConfig(1, 0, WithModuleColorFunc(func(x, y int, dark bool, role ModuleRole) color.Color {
+		calls++
+		assert.Equal(t, qr.GetModule(x, y), dark)
+		assert.Equal(t, roles[y][x], role)
+		return nil
+	}))
+	assert.NoError(t, qr.WriteAsPNG(config, &bytes.Buffer{}))
+	assert.Equal(t, qr.GetSize()*qr.GetSize(), calls)
+
+	err = qr.WriteAsPNG(NewQrCodeImgConfig(1, 0, WithModuleColorFunc(colorDataArea), WithPNGColorMode(PNGColorPaletted)), &bytes.Buffer{})
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+}
+
+func TestQrCode_WriteAsPNG_ModuleColors(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	const scale, border = 10, 1
+	roles := qr.ModuleRoles()
+	for _, shape := range []ModuleShape{ShapeSquare, ShapeCircle, ShapeVerticalBars} {
+		t.Run(shape.String(), func(t *testing.T) {
+			config := NewQrCodeImgConfig(scale, border, WithModuleColorFunc(colorDataArea), WithModuleShape(shape))
+			var buf bytes.Buffer
+			assert.NoError(t, qr.WriteAsPNG(config, &buf))
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+
+			for y := 0; y < qr.GetSize(); y++ {
+				for x := 0; x < qr.GetSize(); x++ {
+					var want color.Color = testWhite
+					switch {
+					case roles[y][x].IsFunction() && qr.GetModule(x, y):
+						want = testBlack
+					case !roles[y][x].IsFunction():
+						want = colorDataArea(x, y, qr.GetModule(x, y), roles[y][x])
+					}
+					center := img.At((x+border)*scale+scale/2, (y+border)*scale+scale/2)
+					assert.Equal(t, color.RGBAModel.Convert(want), color.RGBAModel.Convert(center), "module (%d, %d)", x, y)
+				}
+			}
+			assert.Equal(t, color.RGBAModel.Convert(testWhite), color.RGBAModel.Convert(img.At(0, 0)))
+
+			view, err := qr.Image(config)
+			assert.NoError(t, err)
+			bounds := img.Bounds()
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					if color.NRGBAModel.Convert(img.At(x, y)) != color.NRGBAModel.Convert(view.At(x, y)) {
+						t.Fatalf("pixel (%d, %d) of the PNG and the image differ", x, y)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestModuleStyle_joins_ModuleColors(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	byRow := WithModuleColorFunc(func(x, y int, dark bool, role ModuleRole) color.Color {
+		if y%2 == 0 {
+			return testRed
+		}
+		return testBlue
+	})
+	colored := qr.moduleStyle(NewQrCodeImgConfig(10, 0, WithModuleShape(ShapeVerticalBars), byRow))
+	plain := qr.moduleStyle(NewQrCodeImgConfig(10, 0, WithModuleShape(ShapeVerticalBars)))
+	pairs := 0
+	for y := 0; y+1 < qr.GetSize(); y++ {
+		for x := 0; x < qr.GetSize(); x++ {
+			// Dark modules above each other are in rows of different colors.
+			if plain.joins(x, y, x, y+1) {
+				pairs++
+				assert.False(t, colored.joins(x, y, x, y+1), "module (%d, %d)", x, y)
+			}
+		}
+	}
+	assert.Greater(t, pairs, 0)
+}
+
+func TestQrCode_SVGString_ModuleColors(t *testing.T) {
+	qr, err := EncodeText("https://github.com/piglig/go-qr", Medium)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	roles := qr.ModuleRoles()
+	paths := regexp.MustCompile(`<path d="([^"]*)"[^>]*?( fill="[^"]*")/>`)
+	for _, options := range [][]func(*QrCodeImgConfig){
+		{WithModuleColorFunc(colorDataArea)},
+		{WithModuleColorFunc(colorDataArea), WithOptimalSVG()},
+		{WithModuleColorFunc(colorDataArea), WithModuleShape(ShapeCircle)},
+		{WithModuleColorFunc(colorDataArea), WithModuleShape(ShapeConnected)},
+	} {
+		svg, err := qr.SVGString(NewQrCodeImgConfig(1, 0, options...))
+		assert.NoError(t, err)
+
+		// A path for the light yellow modules, and one for the dark modules of each color.
+		var fills []string
+		for _, match := range paths.FindAllStringSubmatch(svg, -1) {
+			fills = append(fills, match[2])
+		}
+		assert.Equal(t, []string{` fill="#FFFF00"`, ` fill="#000000"`, ` fill="#FF0000"`}, fills, svg)
+	}
+
+	// The outlines of each color contain exactly its modules.
+	svg, err := qr.SVGString(NewQrCodeImgConfig(1, 0, WithModuleColorFunc(colorDataArea), WithOptimalSVG()))
+	assert.NoError(t, err)
+	matches := paths.FindAllStringSubmatch(svg, -1)
+	yellow, black, red := pathLoops(t, matches[0][1]), pathLoops(t, matches[1][1]), pathLoops(t, matches[2][1])
+	for y := 0; y < qr.GetSize(); y++ {
+		for x := 0; x < qr.GetSize(); x++ {
+			dark, function := qr.GetModule(x, y), roles[y][x].IsFunction()
+			px, py := float64(x)+0.5, float64(y)+0.5
+			if insideEvenOdd(yellow, px, py) != (!dark && !function) ||
+				insideEvenOdd(black, px, py) != (dark && function) ||
+				insideEvenOdd(red, px, py) != (dark && !function) {
+				t.Fatalf("module (%d, %d) is drawn wrong", x, y)
+			}
+		}
+	}
+	assert.Equal(t, 1, strings.Count(svg, `fill="#FF0000"`))
+}
@@ -0,0 +1,82 @@
+package go_qr
+
+import "strconv"
+
+// ModuleRole is the role a module plays in a QR code symbol.
+type ModuleRole int
+
+const (
+	RoleData       ModuleRole = iota // Bit of a data codeword
+	RoleEcc                          // Bit of an error correction codeword
+	RoleRemainder                    // Remainder bit after the last codeword
+	RoleFinder                       // Part of one of the three 7x7 finder patterns
+	RoleSeparator                    // Light border around a finder pattern
+	RoleTiming                       // Part of the horizontal or vertical timing pattern
+	RoleAlignment                    // Part of a 5x5 alignment pattern
+	RoleFormat                       // Bit of one of the two copies of the format information
+	RoleVersion                      // Bit of one of the two copies of the version information (version 7 and above)
+	RoleDarkModule                   // The single module next to the bottom left finder pattern which is always dark
+)
+
+// moduleRoleNames maps the ModuleRole to its name.
+var moduleRoleNames = [...]string{"data", "ecc", "remainder", "finder", "separator", "timing", "alignment", "format", "version", "dark module"}
+
+// String returns the name of the role, for example "finder".
+func (r ModuleRole) String() string {
+	if r < RoleData || r > RoleDarkModule {
+		return "ModuleRole(" + strconv.Itoa(int(r)) + ")"
+	}
+	return moduleRoleNames[r]
+}
+
+// IsFunction reports whether the role belongs to a function pattern, that is
+// anything but data, error correction and remainder bits.
+func (r ModuleRole) IsFunction() bool {
+	return r >= RoleFinder
+}
+
+// ModuleRoles returns the role of every module of the QR code, indexed as [y][x]
+// like the coordinates of GetModule.
+//
+// The roles only depend on the version and error correction level, so they are
+// computed again on each call; keep the result when looking up many modules.
+func (q *QrCode) ModuleRoles() [][]ModuleRole {
+	layout := q.functionLayout()
+
+	numDataBits := getNumDataCodewords(q.version, q.errorCorrectionLevel) * 8
+	numCodewordBits := getNumRawDataModules(q.version) / 8 * 8
+	layout.forEachDataModule(func(x, y, i int) {
+		// The interleaved codewords start with the data codewords of all blocks,
+		// followed by the error correction codewords of all blocks.
+		if i < numDataBits {
+			layout.roles[y][x] = RoleData
+		} else if i < numCodewordBits {
+			layout.roles[y][x] = RoleEcc
+		} else {
+			layout.roles[y][x] = RoleRemainder
+		}
+	})
+	return layout.roles
+}
+
+// functionLayout returns an empty QR code of the same version and error correction level,
+// in which only the function patterns are drawn, with isFunction and roles filled in.
+func (q *QrCode) functionLayout() *QrCode {
+	layout := &QrCode{
+		version:              q.version,
+		size:                 q.size,
+		errorCorrectionLevel: q.errorCorrectionLevel,
+		mask:                 q.mask,
+		modules:              make([][]bool, q.size),
+		isFunction:           make([][]bool, q.size),
+		roles:                make([][]ModuleRole, q.size),
+	}
+	for i := 0; i < q.size; i++ {
+		layout.modules[i] = make([]bool, q.size)
+		layout.isFunction[i] = make([]bool, q.size)
+		layout.roles[i] = make([]ModuleRole, q.size)
+	}
+
+	layout.drawFunctionPatterns()
+	return layout
+}
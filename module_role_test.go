@@ -0,0 +1,120 @@
+package go_qr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQrCode_ModuleRoles(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   int
+		ecl       Ecc
+		wantCount map[ModuleRole]int
+	}{
+		{
+			name:    "version 1",
+			version: 1,
+			ecl:     Medium,
+			wantCount: map[ModuleRole]int{
+				RoleData:       16 * 8,
+				RoleEcc:        10 * 8,
+				RoleRemainder:  0,
+				RoleFinder:     3 * 49,
+				RoleSeparator:  3 * 15,
+				RoleTiming:     2 * 5,
+				RoleAlignment:  0,
+				RoleFormat:     2 * 15,
+				RoleVersion:    0,
+				RoleDarkModule: 1,
+			},
+		},
+		{
+			name:    "version 2 with remainder bits",
+			version: 2,
+			ecl:     Low,
+			wantCount: map[ModuleRole]int{
+				RoleData:       34 * 8,
+				RoleEcc:        10 * 8,
+				RoleRemainder:  7,
+				RoleFinder:     3 * 49,
+				RoleSeparator:  3 * 15,
+				RoleTiming:     2 * 9,
+				RoleAlignment:  25,
+				RoleFormat:     2 * 15,
+				RoleVersion:    0,
+				RoleDarkModule: 1,
+			},
+		},
+		{
+			name:    "version 7 with version information",
+			version: 7,
+			ecl:     High,
+			wantCount: map[ModuleRole]int{
+				RoleData:       66 * 8,
+				RoleEcc:        130 * 8,
+				RoleRemainder:  0,
+				RoleFinder:     3 * 49,
+				RoleSeparator:  3 * 15,
+				RoleTiming:     2 * (29 - 5),
+				RoleAlignment:  6 * 25,
+				RoleFormat:     2 * 15,
+				RoleVersion:    2 * 18,
+				RoleDarkModule: 1,
+			},
+		},
+	}
+
+	segs, err := MakeSegments("Hello, world!")
+	if err != nil {
+		t.Fatalf("MakeSegments() error = %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := EncodeSegments(segs, tt.ecl, tt.version, tt.version, -1, false)
+			if err != nil {
+				t.Fatalf("EncodeSegments() error = %v", err)
+			}
+
+			roles := qr.ModuleRoles()
+			assert.Len(t, roles, qr.GetSize())
+
+			count := make(map[ModuleRole]int)
+			for _, row := range roles {
+				assert.Len(t, row, qr.GetSize())
+				for _, role := range row {
+					count[role]++
+				}
+			}
+			for role, want := range tt.wantCount {
+				assert.Equal(t, want, count[role], "number of %s modules", role)
+			}
+
+			assert.Equal(t, RoleFinder, roles[0][0])
+			assert.Equal(t, RoleSeparator, roles[7][7])
+			assert.Equal(t, RoleTiming, roles[6][9])
+			assert.Equal(t, RoleFormat, roles[8][0])
+			assert.Equal(t, RoleDarkModule, roles[qr.GetSize()-8][8])
+			assert.Equal(t, RoleData, roles[qr.GetSize()-1][qr.GetSize()-1])
+			assert.True(t, qr.GetModule(8, qr.GetSize()-8))
+		})
+	}
+}
+
+func TestModuleRole_String(t *testing.T) {
+	assert.Equal(t, "data", RoleData.String())
+	assert.Equal(t, "finder", RoleFinder.String())
+	assert.Equal(t, "dark module", RoleDarkModule.String())
+	assert.Equal(t, "ModuleRole(10)", ModuleRole(10).String())
+}
+
+func TestModuleRole_IsFunction(t *testing.T) {
+	for _, role := range []ModuleRole{RoleData, RoleEcc, RoleRemainder} {
+		assert.False(t, role.IsFunction(), role.String())
+	}
+	for _, role := range []ModuleRole{RoleFinder, RoleSeparator, RoleTiming, RoleAlignment, RoleFormat, RoleVersion, RoleDarkModule} {
+		assert.True(t, role.IsFunction(), role.String())
+	}
+}
@@ -1,37 +1,200 @@
 package go_qr
 
 import (
-	"fmt"
-	"strings"
+	"io"
+	"sort"
 )
 
-func (q *QrCode) toSvgOptimizedString(config *QrCodeImgConfig, lightColor, darkColor string) string {
+// writeOptimizedSVG writes the QR code as SVG with QrCodeImgConfig, in which the dark modules
+// are drawn as the outlines of connected areas, with their outer corners rounded for ShapeConnected.
+// The output only depends on the QR code and the config.
+func (q *QrCode) writeOptimizedSVG(config *QrCodeImgConfig, writer io.Writer) error {
 	scale := config.scale
-	border := config.border
-	// Write the header of the svg.
-	sb := strings.Builder{}
-	sb.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
-	sb.WriteString("<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd\">\n")
-	// Determine the size of the svg.
-	n := q.GetSize()*scale + border*2
-	sb.WriteString(fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" version=\"1.1\" viewBox=\"0 0 %d %d\" stroke=\"none\" style=\"fill-rule:evenodd;clip-rule:evenodd\">\n",
-		n, n))
-	// If light color is set, the background layer is omitted to yield a
+	margins, width, height, frame := config.svgCanvas(q.GetSize())
+	light, dark := config.svgPaints()
+	// Write the header of the svg, with the size of the image.
+	sw := newSVGWriter(writer)
+	if err := q.writeSVGStart(sw, config, width, height, " style=\"fill-rule:evenodd;clip-rule:evenodd\""); err != nil {
+		return err
+	}
+	// If light paint is not set, the background layer is omitted to yield a
 	// transparent background.
-	if lightColor != "" {
-		sb.WriteString("\t<rect width=\"100%\" height=\"100%\" fill=\"" + lightColor + "\"/>\n")
+	if light.visible() {
+		sw.writeString("\t<rect width=\"100%\" height=\"100%\"" + light.attrs() + "/>\n")
+	}
+
+	style := q.moduleStyle(config)
+	style.writeLightModulesSVG(sw, margins)
+
+	// Create a path for each color consisting of several closed loops around
+	// all areas with filled modules of the color, except for the finder
+	// patterns with a style of their own, which are drawn separately.
+	for _, key := range style.colorGroups(true) {
+		sw.writeString("\t<path d=\"")
+		group := style.inGroup(true, key)
+		loops := q.borderLoops(group)
+		if config.options.moduleShape == ShapeConnected {
+			writeRoundedLoops(sw, style, margins, loops, group)
+		} else {
+			writeLoops(sw, margins, scale, loops)
+		}
+		if sw.err != nil {
+			return sw.err
+		}
+		sw.writeString("\"" + style.groupPaint(key, dark).attrs() + "/>\n")
+	}
+	style.writeFinderPaths(sw, margins, dark)
+	if err := style.writeLogoSVG(sw, margins); err != nil {
+		return err
+	}
+	if frame != nil {
+		frame.writeSVG(sw, config)
+	}
+	sw.writeString("</svg>\n")
+
+	return sw.flush()
+}
+
+// writeLoops writes the loops as path data. Each loop is drawn with relative commands from the start
+// of the previous loop, to which each loop returns when it is closed.
+func writeLoops(sw *svgWriter, margins Margins, scale int, loops [][]node) {
+	for i, loop := range loops {
+		// Move cursor to staring node.
+		startNode := loop[0]
+		if i == 0 {
+			startX, startY := startNode.imageXY(margins, scale)
+			sw.writeString("M")
+			sw.writeInt(startX)
+			sw.writeString(",")
+			sw.writeInt(startY)
+		} else {
+			cursor := loops[i-1][0]
+			sw.writeString("m")
+			sw.writeInt((startNode.x - cursor.x) * scale)
+			sw.writeString(",")
+			sw.writeInt((startNode.y - cursor.y) * scale)
+		}
+		// Draw a line to each corner, and the final line to the start.
+		for j := 1; j < len(loop); j++ {
+			if loop[j].x == loop[j-1].x {
+				sw.writeString("v")
+				sw.writeInt((loop[j].y - loop[j-1].y) * scale)
+			} else {
+				sw.writeString("h")
+				sw.writeInt((loop[j].x - loop[j-1].x) * scale)
+			}
+		}
+		sw.writeString("z")
+		if sw.err != nil {
+			return
+		}
 	}
+}
+
+// writeRoundedLoops writes the loops around the modules in group as path data, in which the outer corners
+// of the areas are rounded with the corner radius of the style, except for the corners of modules which keep
+// square or touch dark modules outside the group.
+func writeRoundedLoops(sw *svgWriter, style *moduleStyle, margins Margins, loops [][]node, group func(x, y int) bool) {
+	r := style.radius * float64(style.scale)
+	for _, loop := range loops {
+		n := len(loop)
+		// For each corner, the point at which the line to the corner ends,
+		// and the point at which the line to the next corner starts.
+		enter := make([][2]float64, n)
+		leave := make([][2]float64, n)
+		rounded := make([]bool, n)
+		for i, c := range loop {
+			in := direction(loop[(i+n-1)%n], c)
+			out := direction(c, loop[(i+1)%n])
+			// The module between the two lines is dark at an outer corner, and light at an inner corner.
+			mx, my := c.x+(out[0]-in[0]-1)/2, c.y+(out[1]-in[1]-1)/2
+			// The modules next to it which share the corner are light, as for ShapeConnected in PNG output.
+			sx, sy := 2*(c.x-mx)-1, 2*(c.y-my)-1
+			rounded[i] = r > 0 && group(mx, my) && !style.keepsSquare(mx, my) &&
+				!style.qr.GetModule(mx+sx, my) && !style.qr.GetModule(mx, my+sy)
 
+			x, y := c.imageXY(margins, style.scale)
+			enter[i] = [2]float64{float64(x), float64(y)}
+			leave[i] = enter[i]
+			if rounded[i] {
+				enter[i] = [2]float64{float64(x) - r*float64(in[0]), float64(y) - r*float64(in[1])}
+				leave[i] = [2]float64{float64(x) + r*float64(out[0]), float64(y) + r*float64(out[1])}
+			}
+		}
+
+		sw.writeString("M")
+		sw.writeFloat(leave[0][0])
+		sw.writeString(",")
+		sw.writeFloat(leave[0][1])
+		for j := 1; j <= n; j++ {
+			i := j % n
+			if i == 0 && !rounded[0] {
+				// The line back to the start is drawn by closing the path.
+				break
+			}
+			// The line is left out where the arcs of two corners meet.
+			switch {
+			case enter[i] == leave[j-1]:
+			case loop[i].x == loop[j-1].x:
+				sw.writeString("V")
+				sw.writeFloat(enter[i][1])
+			default:
+				sw.writeString("H")
+				sw.writeFloat(enter[i][0])
+			}
+			if rounded[i] {
+				in := direction(loop[j-1], loop[i])
+				out := direction(loop[i], loop[(i+1)%n])
+				// Turning clockwise on screen, where y points down, sweeps in the positive direction.
+				sweep := "0"
+				if in[0]*out[1]-in[1]*out[0] > 0 {
+					sweep = "1"
+				}
+				sw.writeString("A")
+				sw.writeFloat(r)
+				sw.writeString(",")
+				sw.writeFloat(r)
+				sw.writeString(" 0 0 " + sweep + " ")
+				sw.writeFloat(leave[i][0])
+				sw.writeString(",")
+				sw.writeFloat(leave[i][1])
+			}
+		}
+		sw.writeString("z")
+		if sw.err != nil {
+			return
+		}
+	}
+}
+
+// direction returns the unit vector from node a to node b, which lie on a horizontal or vertical line.
+func direction(a, b node) [2]int {
+	sign := func(v int) int {
+		switch {
+		case v > 0:
+			return 1
+		case v < 0:
+			return -1
+		}
+		return 0
+	}
+	return [2]int{sign(b.x - a.x), sign(b.y - a.y)}
+}
+
+// borderLoops returns the closed loops around all areas of modules which are filled according to dark,
+// as the list of the corners of each loop in drawing order. The loops are started in a fixed order,
+// so that they do not depend on the iteration order of the border graph.
+func (q *QrCode) borderLoops(dark func(x, y int) bool) [][]node {
 	// Create a graph representing the border of all areas with filled modules.
-	nodes := q.assembleBorderGraph()
+	nodes := q.assembleBorderGraph(dark)
 
-	// Create a path consisting of several closed loops, which connect all the
-	// just determined nodes along their edges.
-	sb.WriteString("\t<path d=\"")
+	// Connect all the just determined nodes along their edges.
+	var loops [][]node
 	connectedNodes := make(map[node]bool, len(nodes))
-	for startNode, edges := range nodes {
+	for _, startNode := range sortedNodes(nodes) {
+		edges := nodes[startNode]
 		// Skip the node if it is already connected to a drawn path.
-		if connected, ok := connectedNodes[startNode]; ok && connected {
+		if connectedNodes[startNode] {
 			continue
 		}
 		// Skip the node if it is not in a corner. The starting nodes should
@@ -40,9 +203,7 @@ func (q *QrCode) toSvgOptimizedString(config *QrCodeImgConfig, lightColor, darkC
 		if !edges.formCorner() {
 			continue
 		}
-		// Move cursor to staring node.
-		startX, startY := startNode.imageXY(border, scale)
-		sb.WriteString(fmt.Sprintf("M%d,%d", startX, startY))
+		loop := []node{startNode}
 		// Move along edges until the starting node is reached.
 		prevNode := startNode
 		curNode := startNode
@@ -60,33 +221,39 @@ func (q *QrCode) toSvgOptimizedString(config *QrCodeImgConfig, lightColor, darkC
 			} else {
 				nextNode = curEdges.first
 			}
-			// Only draw a line if the current node is at a corner,
-			// otherwise it is skipped.
+			// Only keep the current node if it is at a corner, otherwise it
+			// is skipped.
 			if curEdges.formCorner() {
-				// Draw a line to the current node.
-				if prevNode.x == curNode.x {
-					_, y := curNode.imageXY(border, scale)
-					// Previous and current node are on a vertical line.
-					sb.WriteString(fmt.Sprintf("V%d", y))
-				} else {
-					// Previous and current node are on a horizontal line.
-					x, _ := curNode.imageXY(border, scale)
-					sb.WriteString(fmt.Sprintf("H%d", x))
-				}
+				loop = append(loop, curNode)
 			}
 			// Mark the current node as being connected to the path.
 			connectedNodes[curNode] = true
 		}
-		// Draw the final line to the start.
-		sb.WriteString(fmt.Sprintf("L%d,%d", startX, startY))
 		// Mark the start node as being connected to the path.
 		connectedNodes[startNode] = true
-
+		loops = append(loops, loop)
 	}
-	sb.WriteString("\" style=\"fill:" + darkColor + "\"/>\n")
-	sb.WriteString("</svg>\n")
+	return loops
+}
 
-	return sb.String()
+// sortedNodes returns the nodes of the graph ordered by row, then column, with the
+// node belonging to the lower module first.
+func sortedNodes(nodes map[node]edges) []node {
+	sorted := make([]node, 0, len(nodes))
+	for n := range nodes {
+		sorted = append(sorted, n)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.y != b.y {
+			return a.y < b.y
+		}
+		if a.x != b.x {
+			return a.x < b.x
+		}
+		return !a.top && b.top
+	})
+	return sorted
 }
 
 // Node represents one node of the svg path creating the QR code.
@@ -111,11 +278,11 @@ type node struct {
 }
 
 // imageXY converts the coordinates of a node in the QR code to the coordinates
-// of a point in the svg image taking the border around the QR code and the
+// of a point in the svg image taking the margins around the QR code and the
 // scale applied to the code into account.
-func (n node) imageXY(border int, scale int) (x int, y int) {
-	x = n.x*scale + border
-	y = n.y*scale + border
+func (n node) imageXY(margins Margins, scale int) (x int, y int) {
+	x = n.x*scale + margins.Left
+	y = n.y*scale + margins.Top
 	return
 }
 
@@ -149,21 +316,21 @@ func addEdge(nodes map[node]edges, fromNode node, toNode node) {
 }
 
 // assembleBorderGraph create a graph data structure representing the border of
-// all connected areas in the QR code with filled modules. The borders between
-// two filled and adjacent modules are all removed.
-func (q *QrCode) assembleBorderGraph() map[node]edges {
+// all connected areas in the QR code with modules filled according to dark.
+// The borders between two filled and adjacent modules are all removed.
+func (q *QrCode) assembleBorderGraph(dark func(x, y int) bool) map[node]edges {
 	nodes := make(map[node]edges)
 	n := q.GetSize()
 	for y := 0; y < n; y++ {
 		for x := 0; x < n; x++ {
-			if q.GetModule(x, y) {
+			if dark(x, y) {
 				// Select which edges of the module have to be in the svg path.
 				// These are all edges which are not adjacent to another filled
 				// module.
-				top := y == 0 || !q.GetModule(x, y-1)
-				right := x == n-1 || !q.GetModule(x+1, y)
-				bottom := y == n-1 || !q.GetModule(x, y+1)
-				left := x == 0 || !q.GetModule(x-1, y)
+				top := y == 0 || !dark(x, y-1)
+				right := x == n-1 || !dark(x+1, y)
+				bottom := y == n-1 || !dark(x, y+1)
+				left := x == 0 || !dark(x-1, y)
 				// Store edges in both directions.
 				if top {
 					leftNode := node{x: x, y: y}
@@ -0,0 +1,130 @@
+package go_qr
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pathLoops parses the closed loops of an optimized SVG path, which uses only M, m, h, v and z commands.
+func pathLoops(t *testing.T, d string) [][][2]int {
+	t.Helper()
+	var loops [][][2]int
+	var start, cur [2]int
+	var loop [][2]int
+	for _, cmd := range regexp.MustCompile(`[MmhvzZ][^MmhvzZ]*`).FindAllString(d, -1) {
+		args := strings.Split(cmd[1:], ",")
+		num := func(i int) int {
+			n, err := strconv.Atoi(args[i])
+			if err != nil {
+				t.Fatalf("invalid path command %q", cmd)
+			}
+			return n
+		}
+		switch cmd[0] {
+		case 'M':
+			cur = [2]int{num(0), num(1)}
+		case 'm':
+			cur = [2]int{cur[0] + num(0), cur[1] + num(1)}
+		case 'h':
+			cur[0] += num(0)
+		case 'v':
+			cur[1] += num(0)
+		case 'z', 'Z':
+			loops = append(loops, loop)
+			loop = nil
+			cur = start
+			continue
+		}
+		if cmd[0] == 'M' || cmd[0] == 'm' {
+			start = cur
+		}
+		loop = append(loop, cur)
+	}
+	assert.Empty(t, loop, "unclosed loop")
+	return loops
+}
+
+// insideEvenOdd checks if the point is inside the loops by the even-odd rule.
+func insideEvenOdd(loops [][][2]int, x, y float64) bool {
+	inside := false
+	for _, loop := range loops {
+		for i := range loop {
+			a, b := loop[i], loop[(i+1)%len(loop)]
+			if a[0] != b[0] {
+				continue
+			}
+			lo, hi := min(a[1], b[1]), max(a[1], b[1])
+			if float64(a[0]) > x && float64(lo) < y && y < float64(hi) {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// optimizedSVG returns the optimized SVG of the QR code.
+func optimizedSVG(t *testing.T, qr *QrCode, config *QrCodeImgConfig) string {
+	t.Helper()
+	sb := strings.Builder{}
+	assert.NoError(t, qr.writeOptimizedSVG(config, &sb))
+	return sb.String()
+}
+
+func TestQrCode_writeOptimizedSVG(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		ecl    Ecc
+		config *QrCodeImgConfig
+	}{
+		{"small", "Hello, world!", Low, NewQrCodeImgConfig(1, 0)},
+		{"scaled with border", "https://github.com/piglig/go-qr", Medium, NewQrCodeImgConfig(10, 7)},
+		{"quiet zone", "WIFI:S:mYwIfI;T:WPA;P:secret_passwordt;H:false;;", High, NewQrCodeImgConfig(3, 0, WithMargins(Margins{Top: 1, Right: 2, Bottom: 3, Left: 4}))},
+		{"large", strings.Repeat("0123456789", 60), Low, NewQrCodeImgConfig(2, 4)},
+	}
+	pathData := regexp.MustCompile(`<path d="([^"]*)"`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := EncodeText(tt.text, tt.ecl)
+			assert.NoError(t, err)
+
+			svg := optimizedSVG(t, qr, tt.config)
+			for i := 0; i < 5; i++ {
+				assert.Equal(t, svg, optimizedSVG(t, qr, tt.config), "output is not reproducible")
+			}
+
+			match := pathData.FindStringSubmatch(svg)
+			if !assert.NotNil(t, match) {
+				return
+			}
+			assert.NotContains(t, match[1], "L")
+			loops := pathLoops(t, match[1])
+			margins := tt.config.svgMargins()
+			scale := float64(tt.config.scale)
+			for y := 0; y < qr.GetSize(); y++ {
+				for x := 0; x < qr.GetSize(); x++ {
+					px := (float64(x)+0.5)*scale + float64(margins.Left)
+					py := (float64(y)+0.5)*scale + float64(margins.Top)
+					if insideEvenOdd(loops, px, py) != qr.GetModule(x, y) {
+						t.Fatalf("module (%d, %d) is drawn wrong", x, y)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestQrCode_writeOptimizedSVG_XMLHeader(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	assert.NoError(t, err)
+
+	svg := optimizedSVG(t, qr, NewQrCodeImgConfig(10, 4, WithOptimalSVG()))
+	assert.True(t, strings.HasPrefix(svg, "<svg "), svg)
+
+	svg = optimizedSVG(t, qr, NewQrCodeImgConfig(10, 4, WithOptimalSVG(), WithSVGXMLHeader(true)))
+	assert.True(t, strings.HasPrefix(svg, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE svg "), svg)
+}
@@ -1,10 +1,49 @@
 package go_qr
 
+import (
+	"image"
+	"image/png"
+	"math"
+)
+
 // qrCodeConfig holds configuration options for generating QR codes.
 type qrCodeConfig struct {
 	// svgXMLHeader indicates whether to include the XML header in the SVG output.
 	svgXMLHeader bool
 	optimalSVG   bool
+	svgColors    *[2]string // Raw CSS light and dark colors of SVG output, replacing the light and dark colors, or nil.
+	svgClasses   *[2]string // CSS classes of the light and dark paths of SVG output, replacing their fills, or nil.
+	svgTitle     *string    // Text of the title element, empty for the default, or nil for none.
+	svgDesc      *string    // Text of the desc element, empty for the default, or nil for none.
+	svgAriaLabel *string    // Accessible name of the svg element, empty for the default, or nil for none.
+	svgID        string     // Id of the svg element, or empty for none.
+	svgClass     string     // Class of the svg element, or empty for none.
+	svgCrisp     bool       // Whether to render the modules with crisp edges.
+	svgSize      *[2]string // Width and height attributes of the svg element, empty for the size in pixels, or nil.
+
+	pngColorMode      PNGColorMode
+	pngCompression    png.CompressionLevel
+	pngPixelsPerMeter int           // Physical resolution written in a pHYs chunk, or 0 to write none.
+	pngText           []pngTextItem // Text written in tEXt or iTXt chunks.
+	pngMetadata       bool          // Whether to write the payload, version, ECL, mask and generator as text.
+
+	moduleShape   ModuleShape
+	cornerRadius  float64 // Corner radius of rounded shapes in modules, or 0 for the default.
+	styledFinders bool    // Whether the finder patterns are drawn in the module shape instead of as squares.
+
+	gradient        *gradient       // Gradient of the dark modules, replacing the dark color, or nil.
+	moduleColorFunc ModuleColorFunc // Color of each module, replacing the light and dark colors, or nil.
+
+	finderStyles        [finderCount]*FinderStyle // Style of the finder pattern in each corner, or nil for the module shape.
+	invalidFinderCorner bool                      // Whether WithFinderStyle was given an unknown corner.
+
+	logo     *Logo       // Logo drawn in the middle over cleared modules, or nil.
+	frame    *Frame      // Frame drawn around the QR code and its quiet zone, or nil.
+	halftone image.Image // Picture drawn as a halftone in the sub-pixels of the modules, or nil.
+
+	printSize       *PrintSize // Physical size from which the scale and quiet zone are computed, or nil.
+	margins         *Margins   // Quiet zone in modules on each side, replacing the border, or nil.
+	strictQuietZone bool       // Whether a quiet zone below MinQuietZone modules is an error.
 }
 
 // WithSVGXMLHeader returns a function that sets the svgXMLHeader option to true
@@ -21,3 +60,130 @@ func WithOptimalSVG() func(*QrCodeImgConfig) {
 		q.options.optimalSVG = true
 	}
 }
+
+// WithSVGColors returns a function that sets raw CSS colors, for example "#FFF", "currentColor" or "var(--qr-dark)",
+// for the light and dark modules of SVG output in the provided QrCodeImgConfig. They replace the light and dark colors
+// of the config in SVG output only. An empty light color omits the background.
+func WithSVGColors(light, dark string) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.svgColors = &[2]string{light, dark}
+	}
+}
+
+// WithSVGClasses returns a function that sets CSS classes for the light background and the dark modules of SVG output
+// in the provided QrCodeImgConfig, so that their colors are styled from CSS. They replace the fill colors.
+// An empty light class omits the background.
+func WithSVGClasses(light, dark string) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.svgClasses = &[2]string{light, dark}
+	}
+}
+
+// WithSVGTitle returns a function that adds a title element to SVG output in the provided QrCodeImgConfig,
+// which is shown as a tooltip and read by screen readers. An empty title is replaced by "QR code".
+func WithSVGTitle(title string) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.svgTitle = &title
+	}
+}
+
+// WithSVGDescription returns a function that adds a desc element to SVG output in the provided QrCodeImgConfig.
+// An empty description is replaced by a description of the payload of the QR code.
+func WithSVGDescription(desc string) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.svgDesc = &desc
+	}
+}
+
+// WithSVGAriaLabel returns a function that sets role="img" and the aria-label attribute on the svg element
+// of SVG output in the provided QrCodeImgConfig. An empty label is replaced by a description of the payload
+// of the QR code.
+func WithSVGAriaLabel(label string) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.svgAriaLabel = &label
+	}
+}
+
+// WithSVGID returns a function that sets the id attribute of the svg element of SVG output
+// in the provided QrCodeImgConfig.
+func WithSVGID(id string) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.svgID = id
+	}
+}
+
+// WithSVGClass returns a function that sets the class attribute of the svg element of SVG output
+// in the provided QrCodeImgConfig.
+func WithSVGClass(class string) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.svgClass = class
+	}
+}
+
+// WithSVGCrispEdges returns a function that sets shape-rendering="crispEdges" on SVG output in the provided
+// QrCodeImgConfig, so that renderers do not anti-alias the edges between modules.
+func WithSVGCrispEdges() func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.svgCrisp = true
+	}
+}
+
+// WithSVGSize returns a function that sets the width and height attributes of the svg element of SVG output
+// in the provided QrCodeImgConfig, for example "100%" or "4cm". An empty width or height is set to the size
+// of the image in pixels. The size replaces the physical size set by WithPrintSize.
+func WithSVGSize(width, height string) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.svgSize = &[2]string{width, height}
+	}
+}
+
+// WithPNGColorMode returns a function that sets the color mode of PNG output in the provided QrCodeImgConfig.
+func WithPNGColorMode(mode PNGColorMode) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.pngColorMode = mode
+	}
+}
+
+// WithPNGCompression returns a function that sets the compression level of PNG output in the provided QrCodeImgConfig.
+func WithPNGCompression(level png.CompressionLevel) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.pngCompression = level
+	}
+}
+
+// WithPNGDPI returns a function that sets the physical resolution of PNG output in dots per inch,
+// which is written in a pHYs chunk so that printing software sizes the QR code correctly.
+func WithPNGDPI(dpi float64) func(*QrCodeImgConfig) {
+	ppm := -1 // Rejected when writing, like any other invalid resolution.
+	if dpi > 0 && dpi/0.0254 <= math.MaxInt32 {
+		ppm = int(math.Round(dpi / 0.0254))
+	}
+	return func(q *QrCodeImgConfig) {
+		q.options.pngPixelsPerMeter = ppm
+	}
+}
+
+// WithPNGPixelsPerMeter returns a function that sets the physical resolution of PNG output in pixels per meter,
+// which is written in a pHYs chunk.
+func WithPNGPixelsPerMeter(ppm int) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.pngPixelsPerMeter = ppm
+	}
+}
+
+// WithPNGText returns a function that adds a text chunk with the given keyword, for example "Title" or "Author",
+// to PNG output. ASCII text is written in a tEXt chunk, other text in a UTF-8 iTXt chunk.
+func WithPNGText(keyword, text string) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.pngText = append(q.options.pngText, pngTextItem{keyword: keyword, text: text})
+	}
+}
+
+// WithPNGMetadata returns a function that makes PNG output include text chunks with the encoded payload,
+// version, error correction level, mask and generator of the QR code. The payload is left out if it
+// cannot be decoded as text.
+func WithPNGMetadata() func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.pngMetadata = true
+	}
+}
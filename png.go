@@ -0,0 +1,323 @@
+package go_qr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// PNGColorMode is the color type in which WriteAsPNG and PNG encode the QR code.
+type PNGColorMode int
+
+const (
+	// PNGColorRGBA writes 8-bit RGBA pixels. This is the default, and supports any colors and gradients.
+	PNGColorRGBA PNGColorMode = iota
+	// PNGColorPaletted writes an image with a palette of the light, dark and finder pattern colors,
+	// which is 1-bit for only the light and dark colors.
+	// Translucent colors are kept in the palette's transparency chunk.
+	PNGColorPaletted
+	// PNGColorGray1 writes a 1-bit grayscale image, which is the smallest encoding.
+	// The light and dark colors must both be opaque black or white.
+	PNGColorGray1
+)
+
+// pngSignature starts every PNG file.
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// pngHeaderSize is the size of the PNG signature and the IHDR chunk, which is always first.
+const pngHeaderSize = len(pngSignature) + 8 + 13 + 4
+
+// pngGenerator is the value of the Software text chunk written by WithPNGMetadata.
+const pngGenerator = "go-qr"
+
+// pngTextItem is a keyword and text written in a PNG text chunk.
+type pngTextItem struct {
+	keyword, text string
+}
+
+// pngChunk is an extra chunk inserted after the IHDR chunk.
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// validatePNGOptions validates the PNG color mode, compression level, resolution and text of QrCodeImgConfig.
+func validatePNGOptions(config *QrCodeImgConfig) error {
+	switch config.options.pngCompression {
+	case png.DefaultCompression, png.NoCompression, png.BestSpeed, png.BestCompression:
+	default:
+		return &ConfigError{Field: "pngCompression", Msg: "unknown PNG compression level"}
+	}
+
+	if (config.options.gradient != nil || config.options.moduleColorFunc != nil) && config.options.pngColorMode != PNGColorRGBA {
+		return &ConfigError{Field: "pngColorMode", Msg: "gradients and module colors require the RGBA PNG color mode"}
+	}
+	if logo := config.options.logo; logo != nil && logo.Image != nil && config.options.pngColorMode != PNGColorRGBA {
+		return &ConfigError{Field: "pngColorMode", Msg: "logo images require the RGBA PNG color mode"}
+	}
+	if config.options.frame != nil && config.options.pngColorMode != PNGColorRGBA {
+		return &ConfigError{Field: "pngColorMode", Msg: "frames require the RGBA PNG color mode"}
+	}
+	switch config.options.pngColorMode {
+	case PNGColorRGBA, PNGColorPaletted:
+	case PNGColorGray1:
+		if _, ok := gray1Bit(config.Light()); !ok {
+			return &ConfigError{Field: "pngColorMode", Msg: "1-bit grayscale PNG requires an opaque black or white light color"}
+		}
+		if _, ok := gray1Bit(config.Dark()); !ok {
+			return &ConfigError{Field: "pngColorMode", Msg: "1-bit grayscale PNG requires an opaque black or white dark color"}
+		}
+		for _, c := range config.finderColors() {
+			if _, ok := gray1Bit(c); !ok {
+				return &ConfigError{Field: "pngColorMode", Msg: "1-bit grayscale PNG requires opaque black or white finder pattern colors"}
+			}
+		}
+	default:
+		return &ConfigError{Field: "pngColorMode", Msg: "unknown PNG color mode"}
+	}
+
+	if config.options.pngPixelsPerMeter < 0 || config.options.pngPixelsPerMeter > math.MaxInt32 {
+		return &ConfigError{Field: "pngPixelsPerMeter", Msg: "PNG resolution must be positive"}
+	}
+	for _, item := range config.options.pngText {
+		if !validPNGKeyword(item.keyword) {
+			return &ConfigError{Field: "pngText", Msg: fmt.Sprintf("invalid PNG text keyword %q", item.keyword)}
+		}
+		if !utf8.ValidString(item.text) || strings.IndexByte(item.text, 0) != -1 {
+			return &ConfigError{Field: "pngText", Msg: fmt.Sprintf("PNG text of keyword %q must be UTF-8 without NUL characters", item.keyword)}
+		}
+	}
+	return nil
+}
+
+// validPNGKeyword checks if keyword is a valid PNG text keyword: 1 to 79 printable ASCII characters,
+// without leading, trailing or consecutive spaces.
+func validPNGKeyword(keyword string) bool {
+	if len(keyword) < 1 || len(keyword) > 79 || keyword[0] == ' ' || keyword[len(keyword)-1] == ' ' || strings.Contains(keyword, "  ") {
+		return false
+	}
+	for i := 0; i < len(keyword); i++ {
+		if keyword[i] < 0x20 || keyword[i] > 0x7E {
+			return false
+		}
+	}
+	return true
+}
+
+// gray1Bit returns the 1-bit grayscale value of c, which is 0 for black and 1 for white.
+// It returns false if c is neither opaque black nor opaque white.
+func gray1Bit(c color.Color) (byte, bool) {
+	r, g, b, a := c.RGBA()
+	switch {
+	case a != 0xFFFF:
+		return 0, false
+	case r == 0 && g == 0 && b == 0:
+		return 0, true
+	case r == 0xFFFF && g == 0xFFFF && b == 0xFFFF:
+		return 1, true
+	}
+	return 0, false
+}
+
+// fillPixels fills pix, which holds an image of the QR code drawn in style with the given stride,
+// with the bytes of each pixel's color, indexed like the palette of the style. If shade is not nil,
+// it returns the bytes of the pixels whose color is not in the palette, and nil for the others.
+// With square modules, each module row is computed once and copied scale times.
+func (q *QrCode) fillPixels(config *QrCodeImgConfig, style *moduleStyle, pix []byte, stride int, colors [][]byte, shade func(x, y int, i uint8) []byte) {
+	width, height := config.pngSize(q.size)
+	rows := style.rowsPerModule()
+	bpp := len(colors[lightIndex])
+	for y := 0; y < height; y += rows {
+		row := pix[y*stride : y*stride+width*bpp]
+		for x := 0; x < width; x++ {
+			i := style.pixelIndex(x, y)
+			if shade != nil {
+				if c := shade(x, y, i); c != nil {
+					copy(row[x*bpp:], c)
+					continue
+				}
+			}
+			copy(row[x*bpp:], colors[i])
+		}
+		for i := 1; i < rows; i++ {
+			copy(pix[(y+i)*stride:], row)
+		}
+	}
+}
+
+// toPalettedImage generates a paletted image based on QrCodeImgConfig,
+// with the light color at index 0, the dark color at index 1 and the finder pattern colors after them.
+func (q *QrCode) toPalettedImage(config *QrCodeImgConfig) *image.Paletted {
+	width, height := config.pngSize(q.size)
+	style := q.moduleStyle(config)
+	result := image.NewPaletted(image.Rect(0, 0, width, height), style.palette)
+	indexes := make([][]byte, len(style.palette))
+	for i := range indexes {
+		indexes[i] = []byte{byte(i)}
+	}
+	q.fillPixels(config, style, result.Pix, result.Stride, indexes, nil)
+	return result
+}
+
+// writeGray1PNG writes the QR code as a 1-bit grayscale PNG, which image/png cannot encode.
+func (q *QrCode) writeGray1PNG(config *QrCodeImgConfig, w io.Writer) error {
+	width, height := config.pngSize(q.size)
+	style := q.moduleStyle(config)
+	rows := style.rowsPerModule()
+	bits := make([]byte, len(style.palette))
+	for i, c := range style.palette {
+		bits[i], _ = gray1Bit(c)
+	}
+
+	var idat bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&idat, zlibLevel(config.options.pngCompression))
+	if err != nil {
+		return err
+	}
+
+	// Each row starts with filter type 0 (none), followed by 8 pixels per byte, most significant bit first.
+	row := make([]byte, 1+(width+7)/8)
+	for y := 0; y < height; y += rows {
+		for i := range row {
+			row[i] = 0
+		}
+		for x := 0; x < width; x++ {
+			row[1+x/8] |= bits[style.pixelIndex(x, y)] << uint(7-x%8)
+		}
+		for i := 0; i < rows; i++ {
+			if _, err := zw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(height))
+	ihdr[8] = 1 // Bit depth
+	ihdr[9] = 0 // Color type grayscale; compression, filter and interlace methods are 0
+
+	if _, err := io.WriteString(w, pngSignature); err != nil {
+		return err
+	}
+	for _, c := range []pngChunk{{"IHDR", ihdr}, {"IDAT", idat.Bytes()}, {"IEND", nil}} {
+		if err := writePNGChunk(w, c.typ, c.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePNGChunk writes a PNG chunk with its length, type, data and CRC.
+func writePNGChunk(w io.Writer, typ string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], typ)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zlibLevel maps a PNG compression level to the zlib compression level used by image/png.
+func zlibLevel(level png.CompressionLevel) int {
+	switch level {
+	case png.NoCompression:
+		return zlib.NoCompression
+	case png.BestSpeed:
+		return zlib.BestSpeed
+	case png.BestCompression:
+		return zlib.BestCompression
+	default:
+		return zlib.DefaultCompression
+	}
+}
+
+// pngChunks returns the pHYs and text chunks requested by QrCodeImgConfig.
+func (q *QrCode) pngChunks(config *QrCodeImgConfig) []pngChunk {
+	var chunks []pngChunk
+	if ppm := config.options.pngPixelsPerMeter; ppm > 0 {
+		data := make([]byte, 9)
+		binary.BigEndian.PutUint32(data[0:], uint32(ppm))
+		binary.BigEndian.PutUint32(data[4:], uint32(ppm))
+		data[8] = 1 // Unit is the meter
+		chunks = append(chunks, pngChunk{typ: "pHYs", data: data})
+	}
+
+	items := config.options.pngText
+	if config.options.pngMetadata {
+		metadata := []pngTextItem{
+			{keyword: "QR Version", text: strconv.Itoa(q.version)},
+			{keyword: "QR Error Correction Level", text: q.errorCorrectionLevel.String()},
+			{keyword: "QR Mask", text: strconv.Itoa(q.mask)},
+			{keyword: "Software", text: pngGenerator},
+		}
+		// The payload is left out when it cannot be decoded as text, like that of raw segments.
+		if payload, err := q.decodeText(); err == nil {
+			metadata = append([]pngTextItem{{keyword: "QR Payload", text: pngText(payload)}}, metadata...)
+		}
+		items = append(metadata, items...)
+	}
+	for _, item := range items {
+		chunks = append(chunks, textChunk(item))
+	}
+	return chunks
+}
+
+// pngText makes text valid for a PNG text chunk, by replacing invalid UTF-8 and removing NUL characters.
+func pngText(text string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(text, "\uFFFD"), "\x00", "")
+}
+
+// textChunk returns a tEXt chunk for ASCII text, and an iTXt chunk with UTF-8 text otherwise.
+func textChunk(item pngTextItem) pngChunk {
+	for i := 0; i < len(item.text); i++ {
+		if item.text[i] >= 0x80 {
+			// Keyword, no compression, no language tag and no translated keyword.
+			data := append([]byte(item.keyword), 0, 0, 0, 0, 0)
+			return pngChunk{typ: "iTXt", data: append(data, item.text...)}
+		}
+	}
+	data := append([]byte(item.keyword), 0)
+	return pngChunk{typ: "tEXt", data: append(data, item.text...)}
+}
+
+// writePNGWithChunks writes the encoded PNG with the extra chunks inserted after its IHDR chunk.
+func writePNGWithChunks(w io.Writer, encoded []byte, chunks []pngChunk) error {
+	if len(encoded) < pngHeaderSize || string(encoded[len(pngSignature)+4:len(pngSignature)+8]) != "IHDR" {
+		return fmt.Errorf("failed to encode PNG: %w: missing IHDR chunk", ErrInvalidArgument)
+	}
+
+	if _, err := w.Write(encoded[:pngHeaderSize]); err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		if err := writePNGChunk(w, c.typ, c.data); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(encoded[pngHeaderSize:])
+	return err
+}
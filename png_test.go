@@ -0,0 +1,221 @@
+package go_qr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQrCode_WriteAsPNG_ColorModes(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		options   []func(*QrCodeImgConfig)
+		light     color.Color
+		dark      color.Color
+		wantModel color.Model
+	}{
+		{"rgba", nil, color.White, color.Black, color.RGBAModel},
+		{"paletted", []func(*QrCodeImgConfig){WithPNGColorMode(PNGColorPaletted)}, color.White, color.RGBA{R: 0x80, A: 0xFF}, nil},
+		{"paletted with transparent light color", []func(*QrCodeImgConfig){WithPNGColorMode(PNGColorPaletted)}, color.Transparent, color.Black, nil},
+		{"gray1", []func(*QrCodeImgConfig){WithPNGColorMode(PNGColorGray1)}, color.White, color.Black, color.GrayModel},
+		{"gray1 inverted", []func(*QrCodeImgConfig){WithPNGColorMode(PNGColorGray1)}, color.Black, color.Gray{Y: 0xFF}, color.GrayModel},
+		{"gray1 best compression", []func(*QrCodeImgConfig){WithPNGColorMode(PNGColorGray1), WithPNGCompression(png.BestCompression)}, color.White, color.Black, color.GrayModel},
+		{"rgba no compression", []func(*QrCodeImgConfig){WithPNGCompression(png.NoCompression)}, color.White, color.Black, color.RGBAModel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewQrCodeImgConfig(3, 2, tt.options...)
+			config.SetLight(tt.light)
+			config.SetDark(tt.dark)
+
+			var buf bytes.Buffer
+			if err := qr.WriteAsPNG(config, &buf); err != nil {
+				t.Fatalf("WriteAsPNG() error = %v", err)
+			}
+			got, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+
+			if tt.wantModel != nil {
+				assert.Equal(t, tt.wantModel, got.ColorModel())
+			} else {
+				assert.IsType(t, &image.Paletted{}, got)
+				assert.Len(t, got.ColorModel(), 2)
+			}
+
+			want := qr.toImage(config)
+			assert.Equal(t, want.Bounds(), got.Bounds())
+			for y := 0; y < want.Bounds().Dy(); y++ {
+				for x := 0; x < want.Bounds().Dx(); x++ {
+					r1, g1, b1, a1 := want.At(x, y).RGBA()
+					r2, g2, b2, a2 := got.At(x, y).RGBA()
+					if [4]uint32{r1, g1, b1, a1} != [4]uint32{r2, g2, b2, a2} {
+						t.Fatalf("At(%d, %d) = %v, want %v", x, y, got.At(x, y), want.At(x, y))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestQrCode_WriteAsPNG_Size(t *testing.T) {
+	qr, err := EncodeText("https://example.com/a/fairly/long/url?with=query&parameters=1", Medium)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	sizeOf := func(options ...func(*QrCodeImgConfig)) int {
+		var buf bytes.Buffer
+		if err := qr.WriteAsPNG(NewQrCodeImgConfig(20, 4, options...), &buf); err != nil {
+			t.Fatalf("WriteAsPNG() error = %v", err)
+		}
+		return buf.Len()
+	}
+
+	rgba := sizeOf()
+	paletted := sizeOf(WithPNGColorMode(PNGColorPaletted))
+	gray1 := sizeOf(WithPNGColorMode(PNGColorGray1))
+	uncompressed := sizeOf(WithPNGColorMode(PNGColorGray1), WithPNGCompression(png.NoCompression))
+	assert.Less(t, paletted, rgba)
+	assert.LessOrEqual(t, gray1, paletted)
+	assert.Less(t, gray1, uncompressed)
+}
+
+func TestQrCode_WriteAsPNG_InvalidOptions(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	grayConfig := NewQrCodeImgConfig(1, 0, WithPNGColorMode(PNGColorGray1))
+	grayConfig.SetDark(color.RGBA{R: 0x80, A: 0xFF})
+
+	tests := []struct {
+		name   string
+		config *QrCodeImgConfig
+	}{
+		{"unknown color mode", NewQrCodeImgConfig(1, 0, WithPNGColorMode(PNGColorMode(3)))},
+		{"unknown compression level", NewQrCodeImgConfig(1, 0, WithPNGCompression(png.CompressionLevel(1)))},
+		{"gray1 with color", grayConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := qr.WriteAsPNG(tt.config, &buf)
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+			assert.Zero(t, buf.Len())
+		})
+	}
+}
+
+// readPNGChunks returns the types and data of the chunks of a PNG file, in order.
+func readPNGChunks(t *testing.T, data []byte) ([]string, map[string][][]byte) {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		t.Fatalf("missing PNG signature")
+	}
+	var types []string
+	chunks := make(map[string][][]byte)
+	for rest := data[len(pngSignature):]; len(rest) > 0; {
+		n := int(binary.BigEndian.Uint32(rest))
+		typ := string(rest[4:8])
+		types = append(types, typ)
+		chunks[typ] = append(chunks[typ], rest[8:8+n])
+		rest = rest[12+n:]
+	}
+	return types, chunks
+}
+
+func TestQrCode_WriteAsPNG_Metadata(t *testing.T) {
+	qr, err := EncodeText("こんにちは, world!", Quartile)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	for _, mode := range []PNGColorMode{PNGColorRGBA, PNGColorPaletted, PNGColorGray1} {
+		t.Run(fmt.Sprintf("color mode %d", mode), func(t *testing.T) {
+			config := NewQrCodeImgConfig(2, 4, WithPNGColorMode(mode), WithPNGDPI(300),
+				WithPNGMetadata(), WithPNGText("Title", "Ticket"), WithPNGText("Comment", "Größe"))
+
+			var buf bytes.Buffer
+			if err := qr.WriteAsPNG(config, &buf); err != nil {
+				t.Fatalf("WriteAsPNG() error = %v", err)
+			}
+			_, err := png.Decode(bytes.NewReader(buf.Bytes()))
+			assert.NoError(t, err)
+
+			types, chunks := readPNGChunks(t, buf.Bytes())
+			assert.Equal(t, []string{"IHDR", "pHYs"}, types[:2])
+			assert.Equal(t, "IEND", types[len(types)-1])
+
+			assert.Equal(t, []byte{0, 0, 0x2E, 0x23, 0, 0, 0x2E, 0x23, 1}, chunks["pHYs"][0]) // 11811 pixels per meter
+			assert.Equal(t, [][]byte{
+				[]byte("QR Version\x00" + strconv.Itoa(qr.GetVersion())),
+				[]byte("QR Error Correction Level\x00" + qr.GetErrorCorrectionLevel().String()),
+				[]byte("QR Mask\x00" + strconv.Itoa(qr.GetMask())),
+				[]byte("Software\x00go-qr"),
+				[]byte("Title\x00Ticket"),
+			}, chunks["tEXt"])
+			assert.Equal(t, [][]byte{
+				[]byte("QR Payload\x00\x00\x00\x00\x00こんにちは, world!"),
+				[]byte("Comment\x00\x00\x00\x00\x00Größe"),
+			}, chunks["iTXt"])
+		})
+	}
+
+	t.Run("pixels per meter", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := qr.WriteAsPNG(NewQrCodeImgConfig(1, 0, WithPNGPixelsPerMeter(4000)), &buf); err != nil {
+			t.Fatalf("WriteAsPNG() error = %v", err)
+		}
+		types, chunks := readPNGChunks(t, buf.Bytes())
+		assert.Equal(t, []string{"IHDR", "pHYs"}, types[:2])
+		assert.Equal(t, []byte{0, 0, 0x0F, 0xA0, 0, 0, 0x0F, 0xA0, 1}, chunks["pHYs"][0])
+		assert.NotContains(t, chunks, "tEXt")
+	})
+
+	t.Run("undecodable payload", func(t *testing.T) {
+		raw := undecodableQrCode(t)
+		var buf bytes.Buffer
+		if err := raw.WriteAsPNG(NewQrCodeImgConfig(1, 0, WithPNGMetadata()), &buf); err != nil {
+			t.Fatalf("WriteAsPNG() error = %v", err)
+		}
+		_, chunks := readPNGChunks(t, buf.Bytes())
+		assert.Equal(t, [][]byte{
+			[]byte("QR Version\x00" + strconv.Itoa(raw.GetVersion())),
+			[]byte("QR Error Correction Level\x00" + raw.GetErrorCorrectionLevel().String()),
+			[]byte("QR Mask\x00" + strconv.Itoa(raw.GetMask())),
+			[]byte("Software\x00go-qr"),
+		}, chunks["tEXt"])
+		assert.NotContains(t, chunks, "iTXt")
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, option := range []func(*QrCodeImgConfig){
+			WithPNGDPI(0),
+			WithPNGDPI(math.NaN()),
+			WithPNGPixelsPerMeter(-1),
+			WithPNGText("", "empty keyword"),
+			WithPNGText(" Title", "leading space"),
+			WithPNGText("Tïtle", "non-ASCII keyword"),
+			WithPNGText("Title", "NUL\x00character"),
+			WithPNGText("Title", "invalid UTF-8 \xff"),
+		} {
+			err := qr.WriteAsPNG(NewQrCodeImgConfig(1, 0, option), &bytes.Buffer{})
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package go_qr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Unit is a unit of physical length.
+type Unit int
+
+const (
+	Millimeter Unit = iota // Millimeters, written as "mm" in SVG
+	Inch                   // Inches, written as "in" in SVG
+)
+
+// String returns the abbreviation of the unit used in SVG lengths, "mm" or "in".
+func (u Unit) String() string {
+	switch u {
+	case Millimeter:
+		return "mm"
+	case Inch:
+		return "in"
+	}
+	return "Unit(" + strconv.Itoa(int(u)) + ")"
+}
+
+// toPixels converts a length in the unit to pixels at the given resolution.
+func (u Unit) toPixels(length, dpi float64) float64 {
+	if u == Millimeter {
+		return length / 25.4 * dpi
+	}
+	return length * dpi
+}
+
+// fromPixels converts a number of pixels at the given resolution to a length in the unit.
+func (u Unit) fromPixels(pixels, dpi float64) float64 {
+	if u == Millimeter {
+		return pixels / dpi * 25.4
+	}
+	return pixels / dpi
+}
+
+// PrintSize describes the physical size of a QR code to be printed.
+//
+// The scale is chosen as the largest integer number of pixels per module for which the QR code,
+// including its quiet zone, is not wider than Width. If Width is 0, the smallest scale which meets
+// MinModuleSize is used. Writing fails if no integer scale meets both.
+type PrintSize struct {
+	Width         float64 // Target width of the QR code including its quiet zone, or 0 to size it by MinModuleSize alone.
+	MinModuleSize float64 // Minimum width of one module, for example 0.33 mm, or 0 for no minimum.
+	Unit          Unit    // Unit of Width and MinModuleSize.
+	DPI           float64 // Resolution of the printer in dots per inch.
+	QuietZone     int     // Width of the quiet zone around the QR code in modules, normally 4. Replaced by WithQuietZone or WithMargins.
+}
+
+// WithPrintSize returns a function that sizes the QR code by physical dimensions in the provided QrCodeImgConfig.
+// The scale and border of the config are replaced by the scale computed from size and its quiet zone. The SVG output carries
+// the physical width and height, and the PNG output the resolution, unless one was set with WithPNGDPI.
+func WithPrintSize(size PrintSize) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.printSize = &size
+	}
+}
+
+// valid checks the print size for values which can never be printed.
+func (p *PrintSize) valid() error {
+	switch {
+	case p.Unit != Millimeter && p.Unit != Inch:
+		return &ConfigError{Field: "printSize", Msg: "unknown unit " + p.Unit.String()}
+	case !(p.DPI > 0) || math.IsInf(p.DPI, 1):
+		return &ConfigError{Field: "printSize", Msg: "DPI must be positive"}
+	case !(p.Width >= 0) || !(p.MinModuleSize >= 0) || math.IsInf(p.Width, 1) || math.IsInf(p.MinModuleSize, 1):
+		return &ConfigError{Field: "printSize", Msg: "width and minimum module size must be non-negative"}
+	case p.Width == 0 && p.MinModuleSize == 0:
+		return &ConfigError{Field: "printSize", Msg: "width or minimum module size must be set"}
+	case p.QuietZone < 0:
+		return &ConfigError{Field: "printSize", Msg: "quiet zone must be non-negative"}
+	}
+	return nil
+}
+
+// scale computes the number of pixels per module for a QR code of the given size in modules,
+// with the quiet zone of the print size.
+func (p *PrintSize) scale(size int) (int, error) {
+	return p.scaleWidth(size + p.QuietZone*2)
+}
+
+// scaleWidth computes the number of pixels per module for a QR code which is the given number of modules wide,
+// including its quiet zone.
+func (p *PrintSize) scaleWidth(width int) (int, error) {
+	modules := float64(width)
+	// Allow for rounding errors in the conversion, so that exact fits are not lost.
+	const epsilon = 1e-9
+
+	minScale := 1
+	if p.MinModuleSize > 0 {
+		minScale = int(math.Max(1, math.Ceil(p.Unit.toPixels(p.MinModuleSize, p.DPI)-epsilon)))
+	}
+	if p.Width == 0 {
+		return minScale, nil
+	}
+
+	maxScale := math.Floor(p.Unit.toPixels(p.Width, p.DPI)/modules + epsilon)
+	if maxScale < float64(minScale) {
+		moduleSize := p.Width / modules
+		if p.MinModuleSize > 0 {
+			return 0, &ConfigError{Field: "printSize", Msg: fmt.Sprintf(
+				"width %g%s at %g DPI gives modules of %.3g%s for %g modules including the quiet zone, "+
+					"which is less than the %d pixels of the minimum module size %g%s",
+				p.Width, p.Unit, p.DPI, moduleSize, p.Unit, modules, minScale, p.MinModuleSize, p.Unit)}
+		}
+		return 0, &ConfigError{Field: "printSize", Msg: fmt.Sprintf(
+			"width %g%s at %g DPI gives modules of %.3g%s for %g modules including the quiet zone, which is less than 1 pixel",
+			p.Width, p.Unit, p.DPI, moduleSize, p.Unit, modules)}
+	}
+	if maxScale > math.MaxInt32 {
+		return 0, &ConfigError{Field: "printSize", Msg: "width too large"}
+	}
+	return int(maxScale), nil
+}
+
+// resolvePrintSize returns a copy of config with the scale computed from its print size for the QR code,
+// or config itself if it has no print size. The quiet zone of the print size is used by quietZone.
+func (q *QrCode) resolvePrintSize(config *QrCodeImgConfig) (*QrCodeImgConfig, error) {
+	p := config.options.printSize
+	if p == nil {
+		return config, nil
+	}
+
+	scale, err := p.scale(q.size)
+	if m := config.options.margins; m != nil {
+		// The margins replace the quiet zone of the print size.
+		scale, err = p.scaleWidth(q.size + m.Left + m.Right)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := *config
+	options := *config.options
+	resolved.options = &options
+	resolved.scale = scale
+	if options.pngPixelsPerMeter == 0 {
+		options.pngPixelsPerMeter = int(math.Round(p.DPI / 0.0254))
+	}
+	return &resolved, nil
+}
+
+// svgSizeAttributes returns the width and height attributes of the SVG element for an image
+// of the given size in pixels: the size set by WithSVGSize, else the physical size,
+// and none without either.
+func svgSizeAttributes(config *QrCodeImgConfig, width, height int) string {
+	if s := config.options.svgSize; s != nil {
+		length := func(length string, pixels int) string {
+			if length == "" {
+				return strconv.Itoa(pixels)
+			}
+			return svgText(length)
+		}
+		return fmt.Sprintf(" width=\"%s\" height=\"%s\"", length(s[0], width), length(s[1], height))
+	}
+
+	p := config.options.printSize
+	if p == nil {
+		return ""
+	}
+
+	length := func(pixels int) string {
+		l := math.Round(p.Unit.fromPixels(float64(pixels), p.DPI)*1000) / 1000
+		return strconv.FormatFloat(l, 'f', -1, 64) + p.Unit.String()
+	}
+	return fmt.Sprintf(" width=\"%s\" height=\"%s\"", length(width), length(height))
+}
@@ -0,0 +1,97 @@
+package go_qr
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintSize_scale(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      PrintSize
+		wantScale int
+		wantErr   bool
+	}{
+		{"width in mm", PrintSize{Width: 30, Unit: Millimeter, DPI: 300, QuietZone: 4}, 12, false},
+		{"width in inches", PrintSize{Width: 1, Unit: Inch, DPI: 600, QuietZone: 4}, 20, false},
+		{"exact fit", PrintSize{Width: 2.9, Unit: Inch, DPI: 100, QuietZone: 4}, 10, false},
+		{"width and minimum module size", PrintSize{Width: 30, MinModuleSize: 0.33, Unit: Millimeter, DPI: 300, QuietZone: 4}, 12, false},
+		{"minimum module size only", PrintSize{MinModuleSize: 0.33, Unit: Millimeter, DPI: 300, QuietZone: 4}, 4, false},
+		{"minimum module size below one pixel", PrintSize{MinModuleSize: 0.1, Unit: Millimeter, DPI: 72}, 1, false},
+		{"modules smaller than the minimum", PrintSize{Width: 10, MinModuleSize: 0.5, Unit: Millimeter, DPI: 300, QuietZone: 4}, 0, true},
+		{"modules smaller than a pixel", PrintSize{Width: 5, Unit: Millimeter, DPI: 72, QuietZone: 4}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.size.scale(21)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidConfig)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantScale, got)
+		})
+	}
+}
+
+func TestPrintSize_valid(t *testing.T) {
+	for _, size := range []PrintSize{
+		{Width: 30, Unit: Unit(2), DPI: 300},
+		{Width: 30, Unit: Millimeter},
+		{Width: -1, Unit: Millimeter, DPI: 300},
+		{Unit: Millimeter, DPI: 300},
+		{Width: 30, Unit: Millimeter, DPI: 300, QuietZone: -1},
+	} {
+		err := NewQrCodeImgConfig(1, 0, WithPrintSize(size)).Valid()
+		assert.ErrorIs(t, err, ErrInvalidConfig, "%+v", size)
+	}
+}
+
+func TestQrCode_PrintSize(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	config := NewQrCodeImgConfig(0, 0, WithPrintSize(PrintSize{Width: 30, Unit: Millimeter, DPI: 300, QuietZone: 4}))
+
+	t.Run("PNG", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := qr.WriteAsPNG(config, &buf); err != nil {
+			t.Fatalf("WriteAsPNG() error = %v", err)
+		}
+		_, chunks := readPNGChunks(t, buf.Bytes())
+		assert.Equal(t, []byte{0, 0, 0x2E, 0x23, 0, 0, 0x2E, 0x23, 1}, chunks["pHYs"][0])
+
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("png.Decode() error = %v", err)
+		}
+		assert.Equal(t, 29*12, img.Bounds().Dx())
+		want := qr.toImage(NewQrCodeImgConfig(12, 4))
+		assert.Equal(t, want.At(4*12, 4*12), img.At(4*12, 4*12))
+		assert.Equal(t, want.At(4*12-1, 4*12-1), img.At(4*12-1, 4*12-1))
+	})
+
+	t.Run("SVG", func(t *testing.T) {
+		svg, err := qr.SVGString(config)
+		assert.NoError(t, err)
+		assert.Contains(t, svg, `width="29.464mm" height="29.464mm" viewBox="0 0 348 348"`)
+		assert.Contains(t, svg, `M48,48h12v12h-12z`)
+
+		optimized, err := qr.SVGString(NewQrCodeImgConfig(0, 0, WithOptimalSVG(),
+			WithPrintSize(PrintSize{MinModuleSize: 0.02, Unit: Inch, DPI: 100, QuietZone: 2})))
+		assert.NoError(t, err)
+		assert.True(t, strings.Contains(optimized, `width="0.5in" height="0.5in" viewBox="0 0 50 50"`), optimized)
+	})
+
+	t.Run("too small", func(t *testing.T) {
+		small := NewQrCodeImgConfig(0, 0, WithPrintSize(PrintSize{Width: 10, MinModuleSize: 0.5, Unit: Millimeter, DPI: 300, QuietZone: 4}))
+		assert.ErrorIs(t, qr.WriteAsPNG(small, &bytes.Buffer{}), ErrInvalidConfig)
+		_, err := qr.SVGString(small)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+}
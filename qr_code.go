@@ -1,7 +1,7 @@
 package go_qr
 
 import (
-	"errors"
+	"bytes"
 	"fmt"
 	"image"
 	"image/color"
@@ -32,6 +32,33 @@ func (e Ecc) FormatBits() int {
 	return eccFormats[e]
 }
 
+// eccNames maps the ECC to its one-letter name used by the QR Code standard.
+var eccNames = [...]string{"L", "M", "Q", "H"}
+
+// String returns the one-letter name of the error correction level: "L", "M", "Q" or "H".
+func (e Ecc) String() string {
+	if e < Low || e > High {
+		return "Ecc(" + strconv.Itoa(int(e)) + ")"
+	}
+	return eccNames[e]
+}
+
+// ParseEcc parses an error correction level from its one-letter name ("L", "M", "Q" or "H")
+// or its full name ("Low", "Medium", "Quartile" or "High"). The case is ignored.
+func ParseEcc(s string) (Ecc, error) {
+	switch strings.ToUpper(s) {
+	case "L", "LOW":
+		return Low, nil
+	case "M", "MEDIUM":
+		return Medium, nil
+	case "Q", "QUARTILE":
+		return Quartile, nil
+	case "H", "HIGH":
+		return High, nil
+	}
+	return Low, fmt.Errorf("%w: unknown error correction level %q", ErrInvalidArgument, s)
+}
+
 // Minimum(1) and Maximum(40) version numbers based on the QR Code Model 2 standard
 const (
 	MinVersion = 1
@@ -81,6 +108,8 @@ type QrCodeImgConfig struct {
 
 // NewQrCodeImgConfig is used to create a QR code generation config with the provided scale of image(scale), border of image(border),
 // and the default light and dark color are white and black.
+// The border is counted in modules by PNG output and in pixels by SVG output; use WithQuietZone
+// for a quiet zone which is counted in modules by both.
 func NewQrCodeImgConfig(scale int, border int, options ...func(config *QrCodeImgConfig)) *QrCodeImgConfig {
 	config := &QrCodeImgConfig{scale: scale, border: border, light: color.White, dark: color.Black, options: &qrCodeConfig{}}
 	for _, o := range options {
@@ -90,15 +119,41 @@ func NewQrCodeImgConfig(scale int, border int, options ...func(config *QrCodeImg
 }
 
 func (q *QrCodeImgConfig) Valid() error {
-	if q.scale <= 0 {
-		return errors.New("scale must be positive")
+	if q.options.printSize != nil {
+		// The scale and quiet zone are computed from the print size.
+		if err := q.options.printSize.valid(); err != nil {
+			return err
+		}
+	} else {
+		if q.scale <= 0 {
+			return &ConfigError{Field: "scale", Msg: "scale must be positive"}
+		}
+
+		if q.border < 0 && q.options.margins == nil {
+			return &ConfigError{Field: "border", Msg: "border must be non-negative"}
+		}
 	}
 
-	if q.border < 0 {
-		return errors.New("border must be non-negative")
+	if err := q.validShape(); err != nil {
+		return err
+	}
+	if err := q.validFinderStyles(); err != nil {
+		return err
+	}
+	if err := q.validGradient(); err != nil {
+		return err
+	}
+	if err := q.validLogo(); err != nil {
+		return err
+	}
+	if err := q.validFrame(); err != nil {
+		return err
+	}
+	if err := q.validHalftone(); err != nil {
+		return err
 	}
 
-	return nil
+	return q.validMargins()
 }
 
 // Light gets light color from QrCodeImgConfig
@@ -128,15 +183,22 @@ type QrCode struct {
 	errorCorrectionLevel Ecc // Error correction level (ECC) of the QR Code.
 	mask                 int // Mask pattern of the QR Code.
 
-	modules    [][]bool // 2D boolean matrix representing dark modules in the QR Code.
-	isFunction [][]bool // 2D boolean matrix distinguishing function from data modules.
+	modules    [][]bool       // 2D boolean matrix representing dark modules in the QR Code.
+	isFunction [][]bool       // 2D boolean matrix distinguishing function from data modules.
+	roles      [][]ModuleRole // 2D matrix of module roles, only allocated while computing ModuleRoles.
 }
 
 // newQrCode is used to create a new QR code with the provided version(ver), error correction level(ecl),
 // data codewords (dataCodewords) and mask value (msk).
 func newQrCode(ver int, ecl Ecc, dataCodewords []byte, msk int) (*QrCode, error) {
+	return newScoredQrCode(ver, ecl, dataCodewords, msk, (*QrCode).getPenaltyScore)
+}
+
+// newScoredQrCode is like newQrCode, but if msk is -1 it chooses the mask with the lowest score,
+// which is computed while the function modules are still marked.
+func newScoredQrCode(ver int, ecl Ecc, dataCodewords []byte, msk int, score func(*QrCode) int) (*QrCode, error) {
 	if msk < -1 || msk > 7 {
-		return nil, errors.New("mask value out of range")
+		return nil, ErrInvalidMask
 	}
 
 	qrCode := &QrCode{
@@ -168,7 +230,7 @@ func newQrCode(ver int, ecl Ecc, dataCodewords []byte, msk int) (*QrCode, error)
 		return nil, err
 	}
 
-	// If mask is -1, choose the best mask based on minimizing penalty score
+	// If mask is -1, choose the best mask based on minimizing the score
 	if msk == -1 {
 		minPenalty := math.MaxInt32
 		for i := 0; i < 8; i++ {
@@ -177,7 +239,7 @@ func newQrCode(ver int, ecl Ecc, dataCodewords []byte, msk int) (*QrCode, error)
 				return nil, err
 			}
 			qrCode.drawFormatBits(i)
-			penalty := qrCode.getPenaltyScore()
+			penalty := score(qrCode)
 			if penalty < minPenalty {
 				msk = i
 				minPenalty = penalty
@@ -213,13 +275,48 @@ func (q *QrCode) GetModule(x, y int) bool {
 	return 0 <= x && x < q.size && 0 <= y && y < q.size && q.modules[y][x]
 }
 
+// GetVersion returns the version of the QR code, between MinVersion and MaxVersion.
+func (q *QrCode) GetVersion() int {
+	return q.version
+}
+
+// GetMask returns the mask pattern of the QR code, between 0 and 7.
+func (q *QrCode) GetMask() int {
+	return q.mask
+}
+
+// GetErrorCorrectionLevel returns the error correction level of the QR code.
+func (q *QrCode) GetErrorCorrectionLevel() Ecc {
+	return q.errorCorrectionLevel
+}
+
+// GetFormatBits returns the raw 15-bit format word of the QR code, as drawn next to the finder patterns.
+// It contains the error correction level and mask, their BCH error correction bits, and is XORed with 0x5412.
+func (q *QrCode) GetFormatBits() int {
+	return formatBits(q.errorCorrectionLevel, q.mask)
+}
+
+// GetVersionBits returns the raw 18-bit version word of the QR code, containing the version and its
+// BCH error correction bits. Versions below 7 have no version information, and 0 is returned.
+func (q *QrCode) GetVersionBits() int {
+	if q.version < 7 {
+		return 0
+	}
+	return versionBits(q.version)
+}
+
 // setFunctionModule sets a given module's status and function status in QrCode.
-// The method takes coordinates x, y and isDark - a flag indicating whether the module should be dark or not.
-func (q *QrCode) setFunctionModule(x, y int, isDark bool) {
+// The method takes coordinates x, y, isDark - a flag indicating whether the module should be dark or not,
+// and the role of the function module.
+func (q *QrCode) setFunctionModule(x, y int, isDark bool, role ModuleRole) {
 	// Assigning darkness state to the respective module in the QR Code.
 	q.modules[y][x] = isDark
 	// Marking this module as a function module.
 	q.isFunction[y][x] = true
+	// Recording the role, if roles are being computed.
+	if q.roles != nil {
+		q.roles[y][x] = role
+	}
 }
 
 // addEccAndInterLeave adds Error Correction Code (ECC) and interleaves to the data received.
@@ -231,7 +328,7 @@ func (q *QrCode) addEccAndInterLeave(data []byte) ([]byte, error) {
 
 	// Checking if the input data length is equal to the number of data codewords
 	if len(data) != numDataCodewords {
-		return nil, errors.New("invalid argument")
+		return nil, ErrInvalidArgument
 	}
 
 	// Get the number of blocks and block ECC length based on the errorCorrectionLevel and QR code version
@@ -291,9 +388,23 @@ func (q *QrCode) drawCodewords(data []byte) error {
 	// The result is divided by 8 to find the number of bytes available.
 	numRawDataModules := getNumRawDataModules(q.version) / 8
 	if len(data) != numRawDataModules {
-		return errors.New("illegal argument")
+		return ErrInvalidArgument
 	}
 
+	q.forEachDataModule(func(x, y, i int) {
+		// Check if there's data left to encode.
+		if i < len(data)*8 {
+			// Write bits into QR Code. Use bitwise operations to extract individual bits from each byte of data.
+			q.modules[y][x] = getBit(int(data[i>>3]), 7-(i&7))
+		}
+	})
+	return nil
+}
+
+// forEachDataModule walks the modules which are not function modules in the zig-zag order in which
+// the codeword bits are placed, and calls f with the coordinates of each and its index i in that order.
+// The remainder bits after the last codeword are included.
+func (q *QrCode) forEachDataModule(f func(x, y, i int)) {
 	i := 0
 	// Iterate over QR Code grid from right-to-left.
 	for right := q.size - 1; right >= 1; right -= 2 {
@@ -313,54 +424,55 @@ func (q *QrCode) drawCodewords(data []byte) error {
 					// If we're going upwards, calculate the corresponding y-coordinate.
 					y = q.size - 1 - vert
 				}
-				// Check if current module is not a function pattern and there's data left to encode.
-				if !q.isFunction[y][x] && i < len(data)*8 {
-					// Write bits into QR Code. Use bitwise operations to extract individual bits from each byte of data.
-					q.modules[y][x] = getBit(int(data[i>>3]), 7-(i&7))
+				// Check if current module is not a function pattern.
+				if !q.isFunction[y][x] {
+					f(x, y, i)
 					i++
 				}
 			}
 		}
 	}
-	return nil
 }
 
 // applyMask applies the chosen mask pattern to the QR code.
 func (q *QrCode) applyMask(msk int) error {
 	if msk < 0 || msk > 7 {
-		return errors.New("mask value out of range")
+		return ErrInvalidMask
 	}
 
 	for y := 0; y < q.size; y++ {
 		for x := 0; x < q.size; x++ {
-			// A boolean variable which will decide if the current cell needs to be inverted or not.
-			var invert bool
-			// Each case corresponds to a different mask pattern defined by the QR code specification.
-			switch msk {
-			case 0:
-				invert = (x+y)%2 == 0
-			case 1:
-				invert = y%2 == 0
-			case 2:
-				invert = x%3 == 0
-			case 3:
-				invert = (x+y)%3 == 0
-			case 4:
-				invert = (x/3+y/2)%2 == 0
-			case 5:
-				invert = x*y%2+x*y%3 == 0
-			case 6:
-				invert = (x*y%2+x*y%3)%2 == 0
-			case 7:
-				invert = ((x+y)%2+x*y%3)%2 == 0
-			}
-			// Invert the cell's color if it is not a function pattern cell and the 'invert' variable is true.
-			q.modules[y][x] = q.modules[y][x] != (invert && !q.isFunction[y][x])
+			// Invert the cell's color if it is not a function pattern cell and the mask inverts it.
+			q.modules[y][x] = q.modules[y][x] != (maskInverts(msk, x, y) && !q.isFunction[y][x])
 		}
 	}
 	return nil
 }
 
+// maskInverts reports whether the mask pattern msk inverts the module at the given coordinates.
+func maskInverts(msk, x, y int) bool {
+	// Each case corresponds to a different mask pattern defined by the QR code specification.
+	switch msk {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	case 7:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+	return false
+}
+
 // getPenaltyScore is a method of the QrCode struct that
 // calculates and returns a penalty score based on several criteria.
 func (q *QrCode) getPenaltyScore() int {
@@ -454,7 +566,7 @@ func (q *QrCode) drawAlignmentPattern(x, y int) {
 			// For each scanned module, we use setFunctionModule to either color it or not,
 			// depending on its distance from the central module.
 			// Modules farther away from the center are made dark (isDark = true), except for those directly adjacent to the center.
-			q.setFunctionModule(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+			q.setFunctionModule(x+dx, y+dy, max(abs(dx), abs(dy)) != 1, RoleAlignment)
 		}
 	}
 }
@@ -467,7 +579,11 @@ func (q *QrCode) drawFinderPattern(x, y int) {
 			dist := max(abs(dx), abs(dy))
 			xx, yy := x+dx, y+dy
 			if 0 <= xx && xx < q.size && 0 <= yy && yy < q.size {
-				q.setFunctionModule(xx, yy, dist != 2 && dist != 4)
+				role := RoleFinder
+				if dist == 4 {
+					role = RoleSeparator
+				}
+				q.setFunctionModule(xx, yy, dist != 2 && dist != 4, role)
 			}
 		}
 	}
@@ -480,19 +596,15 @@ func (q *QrCode) drawVersion() {
 		return
 	}
 
-	rem := q.version
-	for i := 0; i < 12; i++ {
-		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25) // Perform calculation to derive final remainder
-	}
-	bits := q.version<<12 | rem
+	bits := versionBits(q.version)
 
 	// Draw two copies
 	for i := 0; i < 18; i++ {
 		bit := getBit(bits, i)
 		a := q.size - 11 + i%3
 		b := i / 3
-		q.setFunctionModule(a, b, bit)
-		q.setFunctionModule(b, a, bit)
+		q.setFunctionModule(a, b, bit, RoleVersion)
+		q.setFunctionModule(b, a, bit, RoleVersion)
 	}
 }
 
@@ -501,8 +613,8 @@ func (q *QrCode) drawVersion() {
 func (q *QrCode) drawFunctionPatterns() {
 	// Draw horizontal and vertical timing patterns
 	for i := 0; i < q.size; i++ {
-		q.setFunctionModule(6, i, i%2 == 0)
-		q.setFunctionModule(i, 6, i%2 == 0)
+		q.setFunctionModule(6, i, i%2 == 0, RoleTiming)
+		q.setFunctionModule(i, 6, i%2 == 0, RoleTiming)
 	}
 
 	// Draw 3 finder patterns
@@ -551,40 +663,54 @@ func (q *QrCode) getAlignmentPatternPositions() []int {
 	}
 }
 
-// drawFormatBits encodes format information (error correction level and mask number) into the QR Code's format bits.
-func (q *QrCode) drawFormatBits(msk int) {
-	data := q.errorCorrectionLevel.FormatBits()<<3 | msk
+// versionBits computes the 18-bit version word for the given version.
+func versionBits(ver int) int {
+	rem := ver
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25) // Perform calculation to derive final remainder
+	}
+	return ver<<12 | rem
+}
+
+// formatBits computes the 15-bit format word for the given error correction level and mask.
+func formatBits(ecl Ecc, msk int) int {
+	data := ecl.FormatBits()<<3 | msk
 	rem := data
 	for i := 0; i < 10; i++ {
 		rem = (rem << 1) ^ ((rem >> 9) * 0x537) // Computes the remainder of the polynomial division
 	}
 
-	bits := (data<<10 | rem) ^ 0x5412 // Combines the data, remainder and additional bit string
+	return (data<<10 | rem) ^ 0x5412 // Combines the data, remainder and additional bit string
+}
+
+// drawFormatBits encodes format information (error correction level and mask number) into the QR Code's format bits.
+func (q *QrCode) drawFormatBits(msk int) {
+	bits := formatBits(q.errorCorrectionLevel, msk)
 
 	for i := 0; i <= 5; i++ {
-		q.setFunctionModule(8, i, getBit(bits, i))
+		q.setFunctionModule(8, i, getBit(bits, i), RoleFormat)
 	}
-	q.setFunctionModule(8, 7, getBit(bits, 6))
-	q.setFunctionModule(8, 8, getBit(bits, 7))
-	q.setFunctionModule(7, 8, getBit(bits, 8))
+	q.setFunctionModule(8, 7, getBit(bits, 6), RoleFormat)
+	q.setFunctionModule(8, 8, getBit(bits, 7), RoleFormat)
+	q.setFunctionModule(7, 8, getBit(bits, 8), RoleFormat)
 
 	for i := 9; i < 15; i++ {
-		q.setFunctionModule(14-i, 8, getBit(bits, i))
+		q.setFunctionModule(14-i, 8, getBit(bits, i), RoleFormat)
 	}
 
 	for i := 0; i < 8; i++ {
-		q.setFunctionModule(q.size-1-i, 8, getBit(bits, i))
+		q.setFunctionModule(q.size-1-i, 8, getBit(bits, i), RoleFormat)
 	}
 
 	for i := 8; i < 15; i++ {
-		q.setFunctionModule(8, q.size-15+i, getBit(bits, i))
+		q.setFunctionModule(8, q.size-15+i, getBit(bits, i), RoleFormat)
 	}
-	q.setFunctionModule(8, q.size-8, true)
+	q.setFunctionModule(8, q.size-8, true, RoleDarkModule)
 }
 
 // PNG generates a PNG image file for the QR code with QrCodeImgConfig and saves it to given file path
 func (q *QrCode) PNG(config *QrCodeImgConfig, filePath string) error {
-	err := q.validateWritePNGConfig(config)
+	config, err := q.validateWritePNGConfig(config)
 	if err != nil {
 		return err
 	}
@@ -600,7 +726,7 @@ func (q *QrCode) PNG(config *QrCodeImgConfig, filePath string) error {
 
 // WriteAsPNG writes the QR code as PNG with QrCodeImgConfig to the provided io.Writer.
 func (q *QrCode) WriteAsPNG(config *QrCodeImgConfig, writer io.Writer) error {
-	err := q.validateWritePNGConfig(config)
+	config, err := q.validateWritePNGConfig(config)
 	if err != nil {
 		return err
 	}
@@ -608,62 +734,117 @@ func (q *QrCode) WriteAsPNG(config *QrCodeImgConfig, writer io.Writer) error {
 	return q.doWriteAsPNG(config, writer)
 }
 
-// validateWritePNGConfig validates the parameters to write the QR code as PNG
-func (q *QrCode) validateWritePNGConfig(config *QrCodeImgConfig) error {
+// validateWritePNGConfig validates the parameters to write the QR code as PNG,
+// and returns the config with the scale and border computed from its print size.
+func (q *QrCode) validateWritePNGConfig(config *QrCodeImgConfig) (*QrCodeImgConfig, error) {
 	err := config.Valid()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Ensure that the border size combined with QR code size does not exceed the maximum allowed integer value after scaling.
-	if config.border > (math.MaxInt32/2) || int64(q.GetSize())+int64(config.border)*2 > math.MaxInt32/int64(config.scale) {
-		return errors.New("scale or border too large")
+	config, err = q.resolvePrintSize(config)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	// Ensure that the quiet zone combined with QR code size does not exceed the maximum allowed integer value after scaling.
+	m := config.quietZone()
+	if int64(q.GetSize())+int64(m.Left)+int64(m.Right) > math.MaxInt32/int64(config.scale) ||
+		int64(q.GetSize())+int64(m.Top)+int64(m.Bottom) > math.MaxInt32/int64(config.scale) {
+		return nil, &ConfigError{Field: "border", Msg: "scale or border too large"}
+	}
+	if frame := config.options.frame; frame != nil {
+		width, height := config.pngSize(q.size)
+		if l := newFrameLayout(frame, width, height, config.scale); l.width > math.MaxInt32 || l.height > math.MaxInt32 {
+			return nil, &ConfigError{Field: "frame", Msg: "frame too large"}
+		}
+	}
+
+	if err := validatePNGOptions(config); err != nil {
+		return nil, err
+	}
+	return config, q.checkConfigLogo(config)
 }
 
 // doWriteAsPNG writes the QR code as PNG with QrCodeImgConfig to the provided io.Writer.
 func (q *QrCode) doWriteAsPNG(config *QrCodeImgConfig, writer io.Writer) error {
-	rgba := q.toImage(config)
+	chunks := q.pngChunks(config)
+	if len(chunks) == 0 {
+		return q.encodePNG(config, writer)
+	}
 
-	if err := png.Encode(writer, rgba); err != nil {
-		return fmt.Errorf("failed to encode PNG: %w", err)
+	// Encode to a buffer, to insert the extra chunks after the IHDR chunk.
+	var buf bytes.Buffer
+	if err := q.encodePNG(config, &buf); err != nil {
+		return err
+	}
+	return writePNGWithChunks(writer, buf.Bytes(), chunks)
+}
+
+// encodePNG encodes the QR code as PNG in the color mode and with the compression level of QrCodeImgConfig.
+func (q *QrCode) encodePNG(config *QrCodeImgConfig, writer io.Writer) error {
+	var err error
+	switch config.options.pngColorMode {
+	case PNGColorGray1:
+		err = q.writeGray1PNG(config, writer)
+	case PNGColorPaletted:
+		encoder := &png.Encoder{CompressionLevel: config.options.pngCompression}
+		err = encoder.Encode(writer, q.toPalettedImage(config))
+	default:
+		encoder := &png.Encoder{CompressionLevel: config.options.pngCompression}
+		err = encoder.Encode(writer, q.toImage(config))
 	}
 
+	if err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
 	return nil
 }
 
 // toImage generates an RGBA image based on QrCodeImgConfig
 func (q *QrCode) toImage(config *QrCodeImgConfig) *image.RGBA {
-	size := q.GetSize() + config.border*2
-	imageWidth := size * config.scale
-	imageHeight := size * config.scale
-	result := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
-	for y := 0; y < imageHeight; y++ {
-		for x := 0; x < imageWidth; x++ {
-			moduleX := x/config.scale - config.border
-			moduleY := y/config.scale - config.border
-			isDark := q.GetModule(moduleX, moduleY)
-			if isDark {
-				result.Set(x, y, config.Dark())
-			} else {
-				result.Set(x, y, config.Light())
+	width, height := config.pngSize(q.GetSize())
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
+	pix := result.Pix
+	if frame := config.options.frame; frame != nil {
+		l := newFrameLayout(frame, width, height, config.scale)
+		result = image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+		l.draw(result, config)
+		pix = result.Pix[result.PixOffset(l.code.Min.X, l.code.Min.Y):]
+	}
+	style := q.moduleStyle(config)
+	rgbaBytes := func(c color.Color) []byte {
+		rgba := color.RGBAModel.Convert(c).(color.RGBA)
+		return []byte{rgba.R, rgba.G, rgba.B, rgba.A}
+	}
+	colors := make([][]byte, len(style.palette))
+	for i, c := range style.palette {
+		colors[i] = rgbaBytes(c)
+	}
+	var shade func(x, y int, i uint8) []byte
+	if style.shaded() {
+		shade = func(x, y int, i uint8) []byte {
+			moduleX, moduleY := x/style.scale-style.margins.Left, y/style.scale-style.margins.Top
+			if c := style.pixelColor(moduleX, moduleY, float64(x)+0.5, float64(y)+0.5, i); c != nil {
+				return rgbaBytes(c)
 			}
+			return nil
 		}
 	}
+	q.fillPixels(config, style, pix, result.Stride, colors, shade)
 	return result
 }
 
-// SVG generates a SVG file for the QR code with QrCodeImgConfig, light, dark color and saves it to given file path
-func (q *QrCode) SVG(config *QrCodeImgConfig, filePath, light, dark string) error {
-	err := config.Valid()
+// SVG generates a SVG file for the QR code with QrCodeImgConfig and saves it to given file path.
+// The colors are taken from the light and dark colors of QrCodeImgConfig, or from WithSVGColors.
+func (q *QrCode) SVG(config *QrCodeImgConfig, filePath string) error {
+	config, err := q.validateWriteSVGConfig(config)
 	if err != nil {
 		return err
 	}
 
 	if ext := filepath.Ext(filePath); ext != ".svg" {
-		return fmt.Errorf("file type:%v invalid", ext)
+		return fmt.Errorf("%w: file type:%v invalid", ErrInvalidArgument, ext)
 	}
 
 	svgFile, err := os.Create(filePath)
@@ -672,102 +853,145 @@ func (q *QrCode) SVG(config *QrCodeImgConfig, filePath, light, dark string) erro
 	}
 	defer svgFile.Close()
 
-	return q.doWriteAsSVG(config, svgFile, light, dark)
+	return q.doWriteAsSVG(config, svgFile)
 }
 
-// WriteAsSVG writes the QR code as SVG with QrCodeImgConfig, light, dark color to the provided io.Writer.
+// WriteAsSVG writes the QR code as SVG with QrCodeImgConfig to the provided io.Writer.
 //
-// light is the color to use for light sections of the QR code, for example, "#FFFFFF".
-// dark is the color to use for dark sections of the QR code, for example, "#000000".
-func (q *QrCode) WriteAsSVG(config *QrCodeImgConfig, writer io.Writer, light, dark string) error {
-	err := config.Valid()
+// The light and dark colors of QrCodeImgConfig are written as hex colors, with their alpha as fill-opacity.
+// A fully transparent light color leaves out the background. WithSVGColors sets raw CSS colors instead.
+func (q *QrCode) WriteAsSVG(config *QrCodeImgConfig, writer io.Writer) error {
+	config, err := q.validateWriteSVGConfig(config)
 	if err != nil {
 		return err
 	}
 
-	return q.doWriteAsSVG(config, writer, light, dark)
+	return q.doWriteAsSVG(config, writer)
 }
 
-func (q *QrCode) SVGString(config *QrCodeImgConfig, light, dark string) (string, error) {
-	err := config.Valid()
+// SVGString returns the QR code as SVG with QrCodeImgConfig, with the colors used by WriteAsSVG.
+func (q *QrCode) SVGString(config *QrCodeImgConfig) (string, error) {
+	config, err := q.validateWriteSVGConfig(config)
 	if err != nil {
 		return "", err
 	}
 
-	svg := ""
-	if config.options.optimalSVG {
-		svg = q.toSvgOptimizedString(config, light, dark)
-	} else {
-		svg = q.toSVGString(config, light, dark)
+	sb := strings.Builder{}
+	if err := q.writeSVG(config, &sb); err != nil {
+		return "", err
 	}
 
-	return svg, nil
+	return sb.String(), nil
 }
 
-// doWriteAsSVG writes the QR code as SVG with QrCodeImgConfig, light, dark color to the provided io.Writer.
-//
-// light is the color to use for light sections of the QR code, for example, "#FFFFFF".
-// dark is the color to use for dark sections of the QR code, for example, "#000000".
-func (q *QrCode) doWriteAsSVG(config *QrCodeImgConfig, writer io.Writer, light, dark string) error {
-	svg := ""
-	if config.options.optimalSVG {
-		svg = q.toSvgOptimizedString(config, light, dark)
-	} else {
-		svg = q.toSVGString(config, light, dark)
+// validateWriteSVGConfig validates the parameters to write the QR code as SVG,
+// and returns the config with the scale and border computed from its print size.
+func (q *QrCode) validateWriteSVGConfig(config *QrCodeImgConfig) (*QrCodeImgConfig, error) {
+	err := config.Valid()
+	if err != nil {
+		return nil, err
+	}
+	if err := q.checkConfigLogo(config); err != nil {
+		return nil, err
 	}
 
-	_, err := writer.Write([]byte(svg))
-	if err != nil {
+	return q.resolvePrintSize(config)
+}
+
+// doWriteAsSVG writes the QR code as SVG with QrCodeImgConfig to the provided io.Writer.
+func (q *QrCode) doWriteAsSVG(config *QrCodeImgConfig, writer io.Writer) error {
+	if err := q.writeSVG(config, writer); err != nil {
 		return fmt.Errorf("error writing SVG: %w", err)
 	}
 
 	return nil
 }
 
-// toSVGString generates a SVG string image with QrCodeImgConfig, light and dark color
-func (q *QrCode) toSVGString(config *QrCodeImgConfig, lightColor, darkColor string) string {
-	brd := config.border
-	scl := config.scale
-	size := q.GetSize()
+// writeSVG writes the QR code as SVG with QrCodeImgConfig: as outlines for square modules if the optimalSVG
+// option is set without a halftone and for ShapeConnected, and otherwise as one shape per module.
+func (q *QrCode) writeSVG(config *QrCodeImgConfig, writer io.Writer) error {
+	shape := config.options.moduleShape
+	optimal := config.options.optimalSVG && config.options.halftone == nil
+	if shape == ShapeConnected || shape == ShapeSquare && optimal {
+		return q.writeOptimizedSVG(config, writer)
+	}
+	return q.writeModulesSVG(config, writer)
+}
+
+// writeModulesSVG writes the QR code as SVG with QrCodeImgConfig, drawing each dark module in its shape.
+func (q *QrCode) writeModulesSVG(config *QrCodeImgConfig, writer io.Writer) error {
+	mrg, width, height, frame := config.svgCanvas(q.GetSize())
+	light, dark := config.svgPaints()
+	style := q.moduleStyle(config)
 
-	sb := strings.Builder{}
-	sb.Grow(128)
-	if config.options.svgXMLHeader {
-		sb.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
-		sb.WriteString("<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd\">\n")
-	}
-	sb.WriteString(fmt.Sprintf("<svg xmlns=\"http://www.w3.org/2000/svg\" version=\"1.1\" viewBox=\"0 0 %d %d\" stroke=\"none\">\n",
-		(size*scl)+brd*2, (size*scl)+brd*2))
-	sb.WriteString(fmt.Sprintf("\t<rect width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", (size*scl)+brd*2, (size*scl)+brd*2, lightColor))
-	sb.WriteString("\t<path d=\"")
-
-	for y := 0; y < size; y++ {
-		for x := 0; x < size; x++ {
-			if q.GetModule(x, y) {
-				sb.WriteString("M")
-				sb.WriteString(strconv.Itoa((x * scl) + brd))
-				sb.WriteString(",")
-				sb.WriteString(strconv.Itoa((y * scl) + brd))
-				sb.WriteString("h")
-				sb.WriteString(strconv.Itoa(scl))
-				sb.WriteString("v")
-				sb.WriteString(strconv.Itoa(scl))
-				sb.WriteString("h-")
-				sb.WriteString(strconv.Itoa(scl))
-				sb.WriteString("z ")
+	sw := newSVGWriter(writer)
+	if err := q.writeSVGStart(sw, config, width, height, ""); err != nil {
+		return err
+	}
+	if light.visible() {
+		sw.printf("\t<rect width=\"%d\" height=\"%d\"%s/>\n", width, height, light.attrs())
+	}
+	style.writeLightModulesSVG(sw, mrg)
+
+	if style.halftone != nil {
+		style.writeHalftonePath(sw, mrg, dark)
+	} else {
+		// The dark modules of each color are drawn as one path.
+		for _, key := range style.colorGroups(true) {
+			if err := q.writeModulesPath(sw, style, mrg, style.inGroup(true, key)); err != nil {
+				return err
 			}
+			sw.writeString("\"" + style.groupPaint(key, dark).attrs() + "/>\n")
 		}
 	}
+	style.writeFinderPaths(sw, mrg, dark)
+	if err := style.writeLogoSVG(sw, mrg); err != nil {
+		return err
+	}
+	if frame != nil {
+		frame.writeSVG(sw, config)
+	}
+	sw.writeString("</svg>\n")
 
-	// Trim the last space for neatness
-	pathData := strings.TrimSpace(sb.String())
-	sb.Reset() // Reset the builder before writing the final path data
-	sb.WriteString(pathData)
-
-	sb.WriteString(fmt.Sprintf("\" fill=\"%s\"/>\n", darkColor))
-	sb.WriteString("</svg>\n")
+	return sw.flush()
+}
 
-	return sb.String()
+// writeModulesPath writes the start of a path element up to the end of its path data, which draws
+// the modules in group each in the shape of the style, with margins in pixels.
+func (q *QrCode) writeModulesPath(sw *svgWriter, style *moduleStyle, mrg Margins, group func(x, y int) bool) error {
+	scl := style.scale
+	sw.writeString("\t<path d=\"")
+	sep := ""
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !group(x, y) {
+				continue
+			}
+			if style.shape != ShapeSquare && !style.keepsSquare(x, y) {
+				if style.writeModulePath(sw, sep, x, y, (x*scl)+mrg.Left, (y*scl)+mrg.Top) {
+					sep = " "
+				}
+			} else {
+				sw.writeString(sep)
+				sw.writeString("M")
+				sw.writeInt((x * scl) + mrg.Left)
+				sw.writeString(",")
+				sw.writeInt((y * scl) + mrg.Top)
+				sw.writeString("h")
+				sw.writeInt(scl)
+				sw.writeString("v")
+				sw.writeInt(scl)
+				sw.writeString("h-")
+				sw.writeInt(scl)
+				sw.writeString("z")
+				sep = " "
+			}
+		}
+		if sw.err != nil {
+			return sw.err
+		}
+	}
+	return nil
 }
 
 // EncodeText takes a string and an error correction level (ecl),
@@ -802,43 +1026,45 @@ func EncodeStandardSegments(segs []*QrSegment, ecl Ecc) (*QrCode, error) {
 // the specification of minVer, maxVer, mask in addition to the regular parameters.
 // Returns a QR code object or an error.
 func EncodeSegments(segs []*QrSegment, ecl Ecc, minVer, maxVer, mask int, boostEcl bool) (*QrCode, error) {
+	policy := SmallestVersion
+	if boostEcl {
+		policy = BoostEcl
+	}
+	return EncodeSegmentsWithPolicy(segs, ecl, minVer, maxVer, mask, policy)
+}
+
+// EncodeSegmentsWithPolicy is like EncodeSegments, but the version and error correction level
+// are chosen by the given EncodePolicy instead of the boostEcl flag.
+// Returns a QR code object or an error.
+func EncodeSegmentsWithPolicy(segs []*QrSegment, ecl Ecc, minVer, maxVer, mask int, policy EncodePolicy) (*QrCode, error) {
+	return encodeSegments(segs, ecl, minVer, maxVer, mask, policy, (*QrCode).getPenaltyScore)
+}
+
+// encodeSegments implements EncodeSegmentsWithPolicy, choosing the mask with the lowest score if mask is -1.
+func encodeSegments(segs []*QrSegment, ecl Ecc, minVer, maxVer, mask int, policy EncodePolicy, score func(*QrCode) int) (*QrCode, error) {
 	if segs == nil {
-		return nil, errors.New("slice of QrSegment is nil")
+		return nil, fmt.Errorf("%w: slice of QrSegment is nil", ErrInvalidArgument)
 	}
 
 	if !isValidVersion(minVer, maxVer) {
-		return nil, errors.New("invalid version")
-	}
-
-	// Loop over all versions between minVer and maxVer to find a suitable one
-	version, dataUsedBits := 0, 0
-	for version = minVer; ; version++ {
-		// Calculate data capacity bits
-		dataCapacityBits := getNumDataCodewords(version, ecl) * 8
-		// Count total bits used
-		dataUsedBits = getTotalBits(segs, version)
-		if dataUsedBits != -1 && dataUsedBits <= dataCapacityBits {
-			break
-		}
+		return nil, ErrInvalidVersion
+	}
 
-		// If no suitable version found then throw a Segment too long error
-		if version >= maxVer {
-			msg := "Segment too long"
-			if dataUsedBits != -1 {
-				msg = fmt.Sprintf("Data length = %d bits, Max capacity = %d bits", dataUsedBits, dataCapacityBits)
-			}
-			return nil, &DataTooLongException{Msg: msg}
-		}
+	if policy == nil {
+		return nil, fmt.Errorf("%w: encode policy is nil", ErrInvalidArgument)
 	}
 
-	// If boostEcl is set to true, try to upgrade the error correction level
-	// as far as the data can fit.
-	for _, newEcl := range []Ecc{Medium, Quartile, High} {
-		numDataCodewords := getNumDataCodewords(version, newEcl)
-		if boostEcl && dataUsedBits <= numDataCodewords*8 {
-			ecl = newEcl
-		}
+	version, newEcl, err := policy(segs, ecl, minVer, maxVer)
+	if err != nil {
+		return nil, err
+	}
+	if !isValidVersion(version, version) || newEcl < ecl || newEcl > High {
+		return nil, fmt.Errorf("%w: encode policy returned an invalid version or error correction level", ErrInvalidArgument)
+	}
+	if _, err = checkFits(segs, version, newEcl); err != nil {
+		return nil, err
 	}
+	ecl = newEcl
 
 	bb := BitBuffer{}
 	for _, seg := range segs {
@@ -846,15 +1072,15 @@ func EncodeSegments(segs []*QrSegment, ecl Ecc, minVer, maxVer, mask int, boostE
 			continue
 		}
 
-		err := bb.appendBits(seg.mode.modeBits, 4)
+		err := bb.AppendBits(seg.mode.modeBits, 4)
 		if err != nil {
 			return nil, err
 		}
-		err = bb.appendBits(seg.numChars, seg.mode.numCharCountBits(version))
+		err = bb.AppendBits(seg.numChars, seg.mode.numCharCountBits(version))
 		if err != nil {
 			return nil, err
 		}
-		err = bb.appendData(seg.data)
+		err = bb.AppendData(seg.data)
 		if err != nil {
 			return nil, err
 		}
@@ -862,33 +1088,33 @@ func EncodeSegments(segs []*QrSegment, ecl Ecc, minVer, maxVer, mask int, boostE
 
 	// Getting the final data capacity after all segments have been processed.
 	dataCapacityBits := getNumDataCodewords(version, ecl) * 8
-	err := bb.appendBits(0, min(4, dataCapacityBits-bb.len()))
+	err = bb.AppendBits(0, min(4, dataCapacityBits-bb.Len()))
 	if err != nil {
 		return nil, err
 	}
 
-	err = bb.appendBits(0, (8-bb.len()%8)%8)
+	err = bb.AppendBits(0, (8-bb.Len()%8)%8)
 	if err != nil {
 		return nil, err
 	}
 
 	// Writing pad bytes until the BitBuffer length reaches the final data capacity
-	for padByte := 0xEC; bb.len() < dataCapacityBits; padByte ^= 0xEC ^ 0x11 {
-		err = bb.appendBits(padByte, 8)
+	for padByte := 0xEC; bb.Len() < dataCapacityBits; padByte ^= 0xEC ^ 0x11 {
+		err = bb.AppendBits(padByte, 8)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	dataCodewords := make([]byte, bb.len()/8)
-	for i := 0; i < bb.len(); i++ {
+	dataCodewords := make([]byte, bb.Len()/8)
+	for i := 0; i < bb.Len(); i++ {
 		bit := 0
-		if bb.getBit(i) {
+		if bb.GetBit(i) {
 			bit = 1
 		}
 		dataCodewords[i>>3] |= byte(bit << (7 - (i & 7)))
 	}
-	return newQrCode(version, ecl, dataCodewords, mask)
+	return newScoredQrCode(version, ecl, dataCodewords, mask, score)
 }
 
 // isValidVersion is a function that checks if the given minVer and maxVer are within the valid QR code version range.
@@ -987,7 +1213,7 @@ func getNumRawDataModules(ver int) int {
 // The Reed-Solomon divisor computed by this function is used in error detection and correction codes.
 func reedSolomonComputeDivisor(degree int) ([]byte, error) {
 	if degree < 1 || degree > 255 {
-		return nil, errors.New("degree out of range")
+		return nil, fmt.Errorf("%w: degree out of range", ErrInvalidArgument)
 	}
 
 	res := make([]byte, degree)
@@ -596,7 +596,7 @@ func TestQrCode_SVG(t *testing.T) {
 		}
 
 		dest := filepath.Join(tempDir, tt.dest)
-		err = qr.SVG(tt.config, dest, "#FFFFFF", "#000000")
+		err = qr.SVG(tt.config, dest)
 		if (err != nil) != tt.wantErr {
 			t.Errorf("TestQrCode_SVG() error = %v, text = %v, wantErr %v", err, tt.text, tt.wantErr)
 			return
@@ -664,7 +664,6 @@ func TestQrCode_WriteAsSVG(t *testing.T) {
 		},
 	}
 
-	light, dark := "#FFFFFF", "#000000"
 	for _, tt := range tests {
 		qr, err := EncodeText(tt.text, tt.ecl)
 		if err != nil {
@@ -672,7 +671,7 @@ func TestQrCode_WriteAsSVG(t *testing.T) {
 			return
 		}
 
-		err = qr.WriteAsSVG(tt.config, tt.dest, light, dark)
+		err = qr.WriteAsSVG(tt.config, tt.dest)
 		if (err != nil) != tt.wantErr {
 			t.Errorf("TestQrCode_WriteAsSVG() error = %v, wantErr %v", err, tt.wantErr)
 			return
@@ -680,7 +679,11 @@ func TestQrCode_WriteAsSVG(t *testing.T) {
 
 		if !tt.wantErr {
 			actualSVGString := tt.dest.(*bytes.Buffer).String()
-			expectedSVGString := qr.toSVGString(tt.config, light, dark)
+			expectedSVGString, err := qr.SVGString(tt.config)
+			if err != nil {
+				t.Errorf("SVGString() error = %v", err)
+				return
+			}
 
 			if actualSVGString != expectedSVGString {
 				t.Error("TestQrCode_WriteAsSVG() svg string does not match the content of the io.Writer")
@@ -693,19 +696,75 @@ func TestQrCode_WriteAsSVG(t *testing.T) {
 func BenchmarkToSVGString(b *testing.B) {
 	text := "WIFI:S:mYwIfI;T:WPA;P:secret_passwordt;H:false;;"
 	ecl := Medium
-	light, dark := "#FFFFFF", "#000000"
 	for i := 0; i < b.N; i++ {
 		qr, _ := EncodeText(text, ecl)
-		qr.toSVGString(NewQrCodeImgConfig(10, 4), light, dark)
+		qr.writeModulesSVG(NewQrCodeImgConfig(10, 4), io.Discard)
 	}
 }
 
 func BenchmarkToOptimalSVGString(b *testing.B) {
 	text := "WIFI:S:mYwIfI;T:WPA;P:secret_passwordt;H:false;;"
 	ecl := Medium
-	light, dark := "#FFFFFF", "#000000"
 	for i := 0; i < b.N; i++ {
 		qr, _ := EncodeText(text, ecl)
-		qr.toSvgOptimizedString(NewQrCodeImgConfig(10, 4), light, dark)
+		qr.writeOptimizedSVG(NewQrCodeImgConfig(10, 4), io.Discard)
+	}
+}
+
+func TestQrCode_Accessors(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	assert.Equal(t, 1, qr.GetVersion())
+	assert.Equal(t, 2, qr.GetMask())
+	assert.Equal(t, Medium, qr.GetErrorCorrectionLevel())
+	assert.Equal(t, 0b101111001111100, qr.GetFormatBits())
+	assert.Equal(t, 0, qr.GetVersionBits())
+
+	segs, err := MakeSegments("Hello, world!")
+	if err != nil {
+		t.Fatalf("MakeSegments() error = %v", err)
+	}
+	qr, err = EncodeSegments(segs, High, 7, 7, 0, false)
+	if err != nil {
+		t.Fatalf("EncodeSegments() error = %v", err)
+	}
+	assert.Equal(t, 7, qr.GetVersion())
+	assert.Equal(t, 0x07C94, qr.GetVersionBits())
+}
+
+func TestEcc_String(t *testing.T) {
+	assert.Equal(t, "L", Low.String())
+	assert.Equal(t, "M", Medium.String())
+	assert.Equal(t, "Q", Quartile.String())
+	assert.Equal(t, "H", High.String())
+	assert.Equal(t, "Ecc(4)", Ecc(4).String())
+}
+
+func TestParseEcc(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Ecc
+		wantErr bool
+	}{
+		{text: "L", want: Low},
+		{text: "m", want: Medium},
+		{text: "Quartile", want: Quartile},
+		{text: "HIGH", want: High},
+		{text: "X", wantErr: true},
+		{text: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEcc(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEcc(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr {
+			assert.Equal(t, tt.want, got)
+		}
 	}
 }
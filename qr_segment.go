@@ -1,7 +1,7 @@
 package go_qr
 
 import (
-	"errors"
+	"fmt"
 	"math"
 	"regexp"
 	"strconv"
@@ -77,6 +77,24 @@ func (m Mode) isEci() bool {
 	return m.modeBits == Eci.getModeBits()
 }
 
+// String returns the name of the mode.
+func (m Mode) String() string {
+	switch {
+	case m.isNumeric():
+		return "numeric"
+	case m.isAlphanumeric():
+		return "alphanumeric"
+	case m.isByte():
+		return "byte"
+	case m.isKanji():
+		return "kanji"
+	case m.isEci():
+		return "ECI"
+	default:
+		return "unknown"
+	}
+}
+
 var (
 	// numericRegex is a regular expression that matches strings consisting only of numbers (0-9).
 	numericRegex = regexp.MustCompile(`^\d+$`)
@@ -99,7 +117,7 @@ type QrSegment struct {
 // newQrSegment function creates a new QR segment with the given mode, number of characters, and data.
 func newQrSegment(mode Mode, numCh int, data *BitBuffer) (*QrSegment, error) {
 	if numCh < 0 {
-		return nil, errors.New("invalid value")
+		return nil, ErrInvalidArgument
 	}
 	return &QrSegment{
 		mode:     mode,
@@ -113,16 +131,52 @@ func (q *QrSegment) getData() *BitBuffer {
 	return q.data.clone()
 }
 
+// NewRawSegment creates a QR segment from already encoded data bits, for data which the Make functions
+// cannot produce, such as pre-packed numeric runs or a proprietary header.
+// numChars is written as the character count of the segment. It must fit in the character count field
+// of mode for at least the largest versions, and must be 0 for Eci mode.
+func NewRawSegment(mode Mode, numChars int, bits *BitBuffer) (*QrSegment, error) {
+	if mode.modeBits == 0 || len(mode.numBitsCharCount) == 0 {
+		return nil, fmt.Errorf("%w: unknown mode", ErrInvalidArgument)
+	}
+
+	if bits == nil {
+		return nil, fmt.Errorf("%w: BitBuffer is nil", ErrInvalidArgument)
+	}
+
+	if numChars >= 1<<mode.numCharCountBits(MaxVersion) {
+		return nil, fmt.Errorf("%w: %d characters exceed the character count limit of %s mode", ErrInvalidArgument, numChars, mode)
+	}
+
+	return newQrSegment(mode, numChars, bits)
+}
+
+// GetMode returns the mode of the QR segment.
+func (q *QrSegment) GetMode() Mode {
+	return q.mode
+}
+
+// GetNumChars returns the number of characters in the QR segment, as written in its character count field.
+func (q *QrSegment) GetNumChars() int {
+	return q.numChars
+}
+
+// GetBitLength returns the number of data bits in the QR segment, without the mode indicator
+// and character count field.
+func (q *QrSegment) GetBitLength() int {
+	return q.data.Len()
+}
+
 // MakeBytes converts a byte slice into a QR segment in Byte mode.
 // It returns an error if the input data is nil.
 func MakeBytes(data []byte) (*QrSegment, error) {
 	if data == nil {
-		return nil, errors.New("data is nil")
+		return nil, fmt.Errorf("%w: data is nil", ErrInvalidArgument)
 	}
 
 	bb := &BitBuffer{}
 	for _, b := range data {
-		err := bb.appendBits(int(b&0xFF), 8) // Append 8 bits at once to the bit buffer
+		err := bb.AppendBits(int(b&0xFF), 8) // Append 8 bits at once to the bit buffer
 		if err != nil {
 			return nil, err
 		}
@@ -134,14 +188,14 @@ func MakeBytes(data []byte) (*QrSegment, error) {
 // It returns an error if the string contains non-numeric characters.
 func MakeNumeric(digits string) (*QrSegment, error) {
 	if !isNumeric(digits) {
-		return nil, errors.New("string contains non-numeric characters")
+		return nil, invalidCharacter(digits, Numeric, isNumeric)
 	}
 
 	bb := &BitBuffer{}
 	for i := 0; i < len(digits); {
 		n := min(len(digits)-i, 3)              // find the length of the current chunk (up to 3 digits)
 		num, _ := strconv.Atoi(digits[i : i+n]) // convert the current chunk to an integer
-		err := bb.appendBits(num, n*3+1)
+		err := bb.AppendBits(num, n*3+1)
 		if err != nil {
 			return nil, err
 		}
@@ -157,6 +211,17 @@ func isNumeric(numb string) bool {
 	return numericRegex.MatchString(numb)
 }
 
+// invalidCharacter returns an InvalidCharacterError for the first character of text
+// which is not accepted by valid in the given mode.
+func invalidCharacter(text string, mode Mode, valid func(string) bool) error {
+	for i, c := range text {
+		if !valid(string(c)) {
+			return &InvalidCharacterError{Char: c, Position: i, Mode: mode}
+		}
+	}
+	return fmt.Errorf("%w: empty string in %s mode", ErrInvalidArgument, mode)
+}
+
 // isAlphanumeric function takes a string as input and returns a boolean indicating whether the string is alphanumeric.
 // It uses the MatchString method on the alphanumericRegex to check the input string.
 func isAlphanumeric(text string) bool {
@@ -188,7 +253,7 @@ func abs(x int) int {
 // It returns an error if the string contains non-alphanumeric characters.
 func MakeAlphanumeric(text string) (*QrSegment, error) {
 	if !isAlphanumeric(text) {
-		return nil, errors.New("string contains unencodable characters in alphanumeric mode")
+		return nil, invalidCharacter(text, Alphanumeric, isAlphanumeric)
 	}
 
 	bb := &BitBuffer{}
@@ -197,14 +262,14 @@ func MakeAlphanumeric(text string) (*QrSegment, error) {
 		// Process each pair of characters in text.
 		temp := strings.IndexByte(alphanumericCharset, text[i]) * 45
 		temp += strings.IndexByte(alphanumericCharset, text[i+1])
-		err := bb.appendBits(temp, 11)
+		err := bb.AppendBits(temp, 11)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if i < len(text) {
-		err := bb.appendBits(strings.IndexByte(alphanumericCharset, text[i]), 6)
+		err := bb.AppendBits(strings.IndexByte(alphanumericCharset, text[i]), 6)
 		if err != nil {
 			return nil, err
 		}
@@ -245,34 +310,34 @@ func MakeSegments(text string) ([]*QrSegment, error) {
 func MakeEci(val int) (*QrSegment, error) {
 	bb := &BitBuffer{}
 	if val < 0 {
-		return nil, errors.New("ECI assignment value out of range")
+		return nil, fmt.Errorf("%w: ECI assignment value out of range", ErrInvalidArgument)
 	} else if val < (1 << 7) {
-		err := bb.appendBits(val, 8)
+		err := bb.AppendBits(val, 8)
 		if err != nil {
 			return nil, err
 		}
 	} else if val < (1 << 14) {
-		err := bb.appendBits(0b10, 2)
+		err := bb.AppendBits(0b10, 2)
 		if err != nil {
 			return nil, err
 		}
 
-		err = bb.appendBits(val, 14)
+		err = bb.AppendBits(val, 14)
 		if err != nil {
 			return nil, err
 		}
 	} else if val < 1e6 {
-		err := bb.appendBits(0b110, 3)
+		err := bb.AppendBits(0b110, 3)
 		if err != nil {
 			return nil, err
 		}
 
-		err = bb.appendBits(val, 21)
+		err = bb.AppendBits(val, 21)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		return nil, errors.New("ECI assignment value out of range")
+		return nil, fmt.Errorf("%w: ECI assignment value out of range", ErrInvalidArgument)
 	}
 	return newQrSegment(Eci, 0, bb)
 }
@@ -290,7 +355,7 @@ func getTotalBits(segs []*QrSegment, ver int) int {
 		if seg.numChars >= (1 << ccbits) {
 			return -1
 		}
-		res += int64(4 + ccbits + seg.data.len())
+		res += int64(4 + ccbits + seg.data.Len())
 		if res > math.MaxInt32 {
 			return -1
 		}
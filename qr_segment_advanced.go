@@ -2,7 +2,6 @@ package go_qr
 
 import (
 	"encoding/base64"
-	"errors"
 	"fmt"
 	"reflect"
 	"unicode/utf16"
@@ -15,7 +14,7 @@ import (
 // capacity of the version. Returns an array of pointers to QrSegment or an error.
 func MakeSegmentsOptimally(text string, ecl Ecc, minVersion, maxVersion int) ([]*QrSegment, error) {
 	if !isValidVersion(minVersion, maxVersion) {
-		return nil, errors.New("invalid value")
+		return nil, ErrInvalidVersion
 	}
 
 	codePoints, err := toCodePoints(text)
@@ -25,7 +24,7 @@ func MakeSegmentsOptimally(text string, ecl Ecc, minVersion, maxVersion int) ([]
 
 	for version := minVersion; ; version++ {
 		if version == minVersion || version == 10 || version == 27 {
-			segs, err := makeSegmentsOptimallyWithVersion(codePoints, version)
+			segs, err := makeSegmentsOptimallyWithVersion(codePoints, version, ecl)
 			if err != nil {
 				return nil, err
 			}
@@ -36,22 +35,18 @@ func MakeSegmentsOptimally(text string, ecl Ecc, minVersion, maxVersion int) ([]
 				return segs, nil
 			}
 			if version >= maxVersion {
-				msg := "segment too long"
-				if dataUsedBits != -1 {
-					msg = fmt.Sprintf("data length = %d bits, max capacity = %d bits", dataUsedBits, dataCapacityBits)
-				}
-				return nil, &DataTooLongException{Msg: msg}
+				return nil, newDataTooLongException(dataUsedBits, dataCapacityBits, version, ecl)
 			}
 		}
 	}
 }
 
-// makeSegmentsOptimallyWithVersion takes code points and a version number,
-// computes the character modes suitable for that version, and then splits the
-// code points into segments accordingly. Returns an array of pointers to
+// makeSegmentsOptimallyWithVersion takes code points, a version number and the requested
+// error correction level, computes the character modes suitable for that version, and then
+// splits the code points into segments accordingly. Returns an array of pointers to
 // QrSegment or an error.
-func makeSegmentsOptimallyWithVersion(codePoints []int, version int) ([]*QrSegment, error) {
-	charModes, err := computeCharacterModes(codePoints, version)
+func makeSegmentsOptimallyWithVersion(codePoints []int, version int, ecl Ecc) ([]*QrSegment, error) {
+	charModes, err := computeCharacterModes(codePoints, version, ecl)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +60,7 @@ func toCodePoints(s string) ([]int, error) {
 	codePoints := make([]int, len(runes))
 	for i, r := range runes {
 		if utf16.IsSurrogate(r) {
-			return nil, errors.New("invalid UTF-16 string")
+			return nil, &InvalidCharacterError{Char: r, Position: len(string(runes[:i]))}
 		}
 		codePoints[i] = int(r)
 	}
@@ -76,7 +71,7 @@ func toCodePoints(s string) ([]int, error) {
 // countUtf8Bytes counts the number of bytes required to represent a Unicode code point in UTF-8.
 func countUtf8Bytes(cp int) (int, error) {
 	if cp < 0 {
-		return 0, errors.New("invalid code point")
+		return 0, fmt.Errorf("%w: invalid code point", ErrInvalidArgument)
 	} else if cp < 0x80 {
 		return 1, nil
 	} else if cp < 0x800 {
@@ -86,14 +81,15 @@ func countUtf8Bytes(cp int) (int, error) {
 	} else if cp < 0x110000 {
 		return 4, nil
 	} else {
-		return 0, errors.New("invalid code point")
+		return 0, fmt.Errorf("%w: invalid code point", ErrInvalidArgument)
 	}
 }
 
 // computeCharacterModes determines the optimal encoding mode for each character in the input string.
-func computeCharacterModes(codePoints []int, version int) ([]Mode, error) {
+// The error correction level is only used to report a string which is too long for any version.
+func computeCharacterModes(codePoints []int, version int, ecl Ecc) ([]Mode, error) {
 	if len(codePoints) > 7089 {
-		return nil, errors.New("string too long")
+		return nil, newDataTooLongException(-1, getNumDataCodewords(version, ecl)*8, version, ecl)
 	}
 	modeTypes := []Mode{Byte, Alphanumeric, Numeric, Kanji}
 	numModes := len(modeTypes)
@@ -217,7 +213,7 @@ func splitIntoSegments(codePoints []int, charModes []Mode) ([]*QrSegment, error)
 			}
 			res = append(res, qs)
 		} else {
-			return nil, errors.New("invalid mode")
+			return nil, fmt.Errorf("%w: invalid mode", ErrInvalidArgument)
 		}
 		if i >= len(codePoints) {
 			return res, nil
@@ -232,12 +228,12 @@ func splitIntoSegments(codePoints []int, charModes []Mode) ([]*QrSegment, error)
 func MakeKanji(text string) (*QrSegment, error) {
 	bb := &BitBuffer{}
 	runes := []rune(text)
-	for _, c := range text {
+	for i, c := range text {
 		if !isKanji(int(c)) {
-			return nil, errors.New("string contains non-kanji-mode characters")
+			return nil, &InvalidCharacterError{Char: c, Position: i, Mode: Kanji}
 		}
 		val := unicdeToQRKanji[c]
-		err := bb.appendBits(val, 13)
+		err := bb.AppendBits(val, 13)
 		if err != nil {
 			return nil, err
 		}
@@ -363,10 +359,16 @@ const packedQRKanjiToUnicode = "MAAwATAC/wz/DjD7/xr/G/8f/wEwmzCcALT/QACo/z7/4/8/
 
 var unicdeToQRKanji [1 << 16]int
 
+// qrKanjiToUnicode maps the 13-bit Kanji mode values back to Unicode code points, or -1 if unused.
+var qrKanjiToUnicode [1 << 13]int
+
 func init() {
 	for i := range unicdeToQRKanji {
 		unicdeToQRKanji[i] = -1
 	}
+	for i := range qrKanjiToUnicode {
+		qrKanjiToUnicode[i] = -1
+	}
 
 	bytes, _ := base64.StdEncoding.DecodeString(packedQRKanjiToUnicode)
 	for i := 0; i < len(bytes); i += 2 {
@@ -376,5 +378,6 @@ func init() {
 		}
 
 		unicdeToQRKanji[c] = i / 2
+		qrKanjiToUnicode[i/2] = c
 	}
 }
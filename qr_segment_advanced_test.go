@@ -1,8 +1,11 @@
 package go_qr
 
 import (
-	"github.com/stretchr/testify/assert"
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMakeSegmentsOptimally(t *testing.T) {
@@ -190,6 +193,19 @@ func TestMakeSegmentsOptimally(t *testing.T) {
 	}
 }
 
+func TestMakeSegmentsOptimally_StringTooLong(t *testing.T) {
+	_, err := MakeSegmentsOptimally(strings.Repeat("1", 7090), High, 1, 40)
+	var tooLong *DataTooLongException
+	if !errors.As(err, &tooLong) {
+		t.Fatalf("MakeSegmentsOptimally() error = %v, want DataTooLongException", err)
+	}
+	assert.ErrorIs(t, err, ErrDataTooLong)
+	assert.Equal(t, High, tooLong.Ecl)
+	assert.Equal(t, -1, tooLong.UsedBits)
+	assert.Equal(t, 1, tooLong.Version)
+	assert.Equal(t, getNumDataCodewords(1, High)*8, tooLong.CapacityBits)
+}
+
 func TestCountUtf8Bytes(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -559,3 +559,49 @@ func TestQrSegment_GetData(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRawSegment(t *testing.T) {
+	digits := &BitBuffer{}
+	assert.NoError(t, digits.AppendBits(123, 10))
+	assert.NoError(t, digits.AppendBits(45, 7))
+
+	tests := []struct {
+		name     string
+		mode     Mode
+		numChars int
+		bits     *BitBuffer
+		wantErr  bool
+	}{
+		{name: "numeric", mode: Numeric, numChars: 5, bits: digits},
+		{name: "largest byte count", mode: Byte, numChars: 1<<16 - 1, bits: &BitBuffer{}},
+		{name: "byte count over limit", mode: Byte, numChars: 1 << 16, bits: &BitBuffer{}, wantErr: true},
+		{name: "kanji count over limit", mode: Kanji, numChars: 1 << 12, bits: &BitBuffer{}, wantErr: true},
+		{name: "eci with characters", mode: Eci, numChars: 1, bits: &BitBuffer{}, wantErr: true},
+		{name: "negative count", mode: Numeric, numChars: -1, bits: &BitBuffer{}, wantErr: true},
+		{name: "nil bits", mode: Numeric, numChars: 0, bits: nil, wantErr: true},
+		{name: "unknown mode", mode: Mode{}, numChars: 0, bits: &BitBuffer{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seg, err := NewRawSegment(tt.mode, tt.numChars, tt.bits)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRawSegment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			assert.Equal(t, tt.mode, seg.GetMode())
+			assert.Equal(t, tt.numChars, seg.GetNumChars())
+			assert.Equal(t, tt.bits.Len(), seg.GetBitLength())
+		})
+	}
+
+	raw, err := NewRawSegment(Numeric, 5, digits)
+	assert.NoError(t, err)
+	made, err := MakeNumeric("12345")
+	assert.NoError(t, err)
+	assert.Equal(t, made, raw)
+}
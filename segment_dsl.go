@@ -0,0 +1,324 @@
+package go_qr
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParseSegments parses a segment description into QR segments. A description is a list of
+// segments separated by '|', each written as a mode prefix and its payload:
+//
+//	N:0123456789 | A:HELLO WORLD | B:hex:DEADBEEF | K:点茗 | ECI:26
+//
+// The prefixes are N (MakeNumeric), A (MakeAlphanumeric), B (MakeBytes), K (MakeKanji)
+// and ECI (MakeEci), and are case-insensitive. A B payload is taken as UTF-8 text,
+// unless it starts with "hex:" or "base64:".
+// Whitespace around a segment is ignored. A payload which contains '|' or '"', or starts or ends
+// with whitespace, can be written as a double-quoted Go string literal, for example B:"a | b".
+// A quoted B payload is always taken as text.
+//
+// Errors are returned as *SegmentSyntaxError, with the byte offset of the problem in desc.
+func ParseSegments(desc string) ([]*QrSegment, error) {
+	p := &segmentParser{desc: desc}
+	segs := make([]*QrSegment, 0)
+	if strings.TrimSpace(desc) == "" {
+		return segs, nil
+	}
+
+	for {
+		seg, err := p.parseSegment()
+		if err != nil {
+			return nil, err
+		}
+		segs = append(segs, seg)
+
+		p.skipSpace()
+		if p.pos == len(p.desc) {
+			return segs, nil
+		}
+		if p.desc[p.pos] != '|' {
+			return nil, p.errorf(p.pos, nil, "expected '|' after segment")
+		}
+		p.pos++
+	}
+}
+
+// FormatSegments formats QR segments as a segment description, which ParseSegments parses
+// back into the same segments. Byte segments which are not printable UTF-8 text are written in hex.
+func FormatSegments(segs []*QrSegment) (string, error) {
+	items := make([]string, 0, len(segs))
+	for _, seg := range segs {
+		if seg == nil {
+			continue
+		}
+
+		payload, eci, err := segmentPayload(seg)
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case seg.mode.isNumeric():
+			items = append(items, "N:"+quotePayload(string(payload)))
+		case seg.mode.isAlphanumeric():
+			items = append(items, "A:"+quotePayload(string(payload)))
+		case seg.mode.isKanji():
+			items = append(items, "K:"+quotePayload(string(payload)))
+		case seg.mode.isEci():
+			items = append(items, "ECI:"+strconv.Itoa(eci))
+		default:
+			if isPrintableText(payload) {
+				text := string(payload)
+				if strings.HasPrefix(text, "hex:") || strings.HasPrefix(text, "base64:") {
+					text = strconv.Quote(text)
+				} else {
+					text = quotePayload(text)
+				}
+				items = append(items, "B:"+text)
+			} else {
+				items = append(items, "B:hex:"+strings.ToUpper(hex.EncodeToString(payload)))
+			}
+		}
+	}
+	return strings.Join(items, " | "), nil
+}
+
+// segmentParser holds the state of ParseSegments.
+type segmentParser struct {
+	desc string
+	pos  int
+}
+
+// errorf returns a SegmentSyntaxError at the given offset.
+func (p *segmentParser) errorf(offset int, err error, format string, args ...interface{}) error {
+	return &SegmentSyntaxError{Offset: offset, Msg: fmt.Sprintf(format, args...), Err: err}
+}
+
+// skipSpace advances past any whitespace.
+func (p *segmentParser) skipSpace() {
+	for p.pos < len(p.desc) {
+		r, size := utf8.DecodeRuneInString(p.desc[p.pos:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		p.pos += size
+	}
+}
+
+// parseSegment parses one segment, starting at the current position.
+func (p *segmentParser) parseSegment() (*QrSegment, error) {
+	p.skipSpace()
+	start := p.pos
+	colon := strings.IndexAny(p.desc[start:], ":|")
+	if colon == -1 || p.desc[start+colon] != ':' {
+		return nil, p.errorf(start, nil, "expected mode prefix followed by ':'")
+	}
+	kind := strings.ToUpper(p.desc[start : start+colon])
+	p.pos = start + colon + 1
+
+	payload, payloadPos, quoted, err := p.parsePayload()
+	if err != nil {
+		return nil, err
+	}
+
+	var seg *QrSegment
+	switch kind {
+	case "N":
+		seg, err = MakeNumeric(payload)
+	case "A":
+		seg, err = MakeAlphanumeric(payload)
+	case "K":
+		seg, err = MakeKanji(payload)
+	case "B":
+		return p.parseBytes(payload, payloadPos, quoted)
+	case "ECI":
+		val, convErr := strconv.Atoi(payload)
+		if convErr != nil {
+			return nil, p.errorf(payloadPos, convErr, "invalid ECI assignment value %q", payload)
+		}
+		seg, err = MakeEci(val)
+	default:
+		return nil, p.errorf(start, nil, "unknown mode prefix %q", p.desc[start:start+colon])
+	}
+
+	if err != nil {
+		return nil, p.payloadError(payloadPos, quoted, err)
+	}
+	return seg, nil
+}
+
+// parsePayload parses a quoted or unquoted payload, starting at the current position.
+// It returns the payload, its offset in the description and whether it was quoted.
+func (p *segmentParser) parsePayload() (string, int, bool, error) {
+	p.skipSpace()
+	start := p.pos
+	if start < len(p.desc) && p.desc[start] == '"' {
+		// Find the closing quote, skipping escaped characters.
+		end := start + 1
+		for ; end < len(p.desc) && p.desc[end] != '"'; end++ {
+			if p.desc[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(p.desc) {
+			return "", start, true, p.errorf(start, nil, "unterminated quoted payload")
+		}
+
+		payload, err := strconv.Unquote(p.desc[start : end+1])
+		if err != nil {
+			return "", start, true, p.errorf(start, err, "invalid quoted payload")
+		}
+		p.pos = end + 1
+		return payload, start, true, nil
+	}
+
+	end := strings.IndexByte(p.desc[start:], '|')
+	if end == -1 {
+		end = len(p.desc) - start
+	}
+	p.pos = start + end
+	return strings.TrimRightFunc(p.desc[start:p.pos], unicode.IsSpace), start, false, nil
+}
+
+// parseBytes makes a Byte mode segment from a payload, which is hex, base64 or text.
+func (p *segmentParser) parseBytes(payload string, payloadPos int, quoted bool) (*QrSegment, error) {
+	data := []byte(payload)
+	if !quoted && strings.HasPrefix(payload, "hex:") {
+		digits := payload[len("hex:"):]
+		decoded, err := hex.DecodeString(digits)
+		if err != nil {
+			offset := payloadPos + len(payload)
+			if i := strings.IndexFunc(digits, func(r rune) bool { return !strings.ContainsRune("0123456789abcdefABCDEF", r) }); i != -1 {
+				offset = payloadPos + len("hex:") + i
+			}
+			return nil, p.errorf(offset, err, "invalid hex payload")
+		}
+		data = decoded
+	} else if !quoted && strings.HasPrefix(payload, "base64:") {
+		decoded, err := base64.StdEncoding.DecodeString(payload[len("base64:"):])
+		if err != nil {
+			offset := payloadPos + len("base64:")
+			var corrupt base64.CorruptInputError
+			if errors.As(err, &corrupt) {
+				offset += int(corrupt)
+			}
+			return nil, p.errorf(offset, err, "invalid base64 payload")
+		}
+		data = decoded
+	}
+
+	seg, err := MakeBytes(data)
+	if err != nil {
+		return nil, p.payloadError(payloadPos, quoted, err)
+	}
+	return seg, nil
+}
+
+// payloadError converts an error of a Make function into a SegmentSyntaxError.
+// The position of an invalid character is translated to an offset in the description,
+// if the payload was not quoted.
+func (p *segmentParser) payloadError(payloadPos int, quoted bool, err error) error {
+	offset := payloadPos
+	var invalidChar *InvalidCharacterError
+	if errors.As(err, &invalidChar) && !quoted {
+		offset += invalidChar.Position
+	}
+	return p.errorf(offset, err, "%s", err.Error())
+}
+
+// quotePayload quotes a payload if it could not be parsed back unquoted.
+func quotePayload(payload string) string {
+	if strings.ContainsAny(payload, "|\"") || strings.TrimSpace(payload) != payload {
+		return strconv.Quote(payload)
+	}
+	return payload
+}
+
+// isPrintableText checks if data is valid UTF-8 consisting of printable characters and spaces only.
+func isPrintableText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentPayload decodes the data bits of a segment back to the characters they encode.
+// For Eci mode, the assignment value is returned in eci instead.
+func segmentPayload(seg *QrSegment) (payload []byte, eci int, err error) {
+	r := NewBitReader(seg.data)
+	switch {
+	case seg.mode.isNumeric():
+		for n := seg.numChars; n > 0; n -= 3 {
+			digits := min(n, 3)
+			val, err := r.ReadBits(digits*3 + 1)
+			if err != nil {
+				return nil, 0, err
+			}
+			if val >= [...]int{1, 10, 100, 1000}[digits] {
+				return nil, 0, fmt.Errorf("%w: invalid numeric data", ErrInvalidArgument)
+			}
+			payload = append(payload, fmt.Sprintf("%0*d", digits, val)...)
+		}
+	case seg.mode.isAlphanumeric():
+		for n := seg.numChars; n > 0; n -= 2 {
+			if n == 1 {
+				val, err := r.ReadBits(6)
+				if err != nil || val >= len(alphanumericCharset) {
+					return nil, 0, fmt.Errorf("%w: invalid alphanumeric data", ErrInvalidArgument)
+				}
+				payload = append(payload, alphanumericCharset[val])
+				break
+			}
+			val, err := r.ReadBits(11)
+			if err != nil || val >= 45*45 {
+				return nil, 0, fmt.Errorf("%w: invalid alphanumeric data", ErrInvalidArgument)
+			}
+			payload = append(payload, alphanumericCharset[val/45], alphanumericCharset[val%45])
+		}
+	case seg.mode.isByte():
+		for i := 0; i < seg.numChars; i++ {
+			val, err := r.ReadBits(8)
+			if err != nil {
+				return nil, 0, err
+			}
+			payload = append(payload, byte(val))
+		}
+	case seg.mode.isKanji():
+		for i := 0; i < seg.numChars; i++ {
+			val, err := r.ReadBits(13)
+			if err != nil {
+				return nil, 0, err
+			}
+			if qrKanjiToUnicode[val] == -1 {
+				return nil, 0, fmt.Errorf("%w: invalid kanji data", ErrInvalidArgument)
+			}
+			payload = utf8.AppendRune(payload, rune(qrKanjiToUnicode[val]))
+		}
+	case seg.mode.isEci():
+		prefix, err := r.ReadBits(1)
+		length := 7
+		for ; err == nil && prefix == 1 && length < 21; length += 7 {
+			prefix, err = r.ReadBits(1)
+		}
+		if err == nil {
+			eci, err = r.ReadBits(length)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	default:
+		return nil, 0, fmt.Errorf("%w: unknown mode", ErrInvalidArgument)
+	}
+	return payload, eci, nil
+}
@@ -0,0 +1,186 @@
+package go_qr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSegments(t *testing.T) {
+	mustSeg := func(seg *QrSegment, err error) *QrSegment {
+		if err != nil {
+			t.Fatalf("making segment: %v", err)
+		}
+		return seg
+	}
+
+	tests := []struct {
+		name string
+		desc string
+		want []*QrSegment
+	}{
+		{
+			name: "all modes",
+			desc: "N:0123456789 | A:HELLO WORLD | B:hex:DEADBEEF | K:点茗 | ECI:26",
+			want: []*QrSegment{
+				mustSeg(MakeNumeric("0123456789")),
+				mustSeg(MakeAlphanumeric("HELLO WORLD")),
+				mustSeg(MakeBytes([]byte{0xDE, 0xAD, 0xBE, 0xEF})),
+				mustSeg(MakeKanji("点茗")),
+				mustSeg(MakeEci(26)),
+			},
+		},
+		{
+			name: "lower case prefixes and no spaces",
+			desc: "n:42|b:hello|eci:899",
+			want: []*QrSegment{
+				mustSeg(MakeNumeric("42")),
+				mustSeg(MakeBytes([]byte("hello"))),
+				mustSeg(MakeEci(899)),
+			},
+		},
+		{
+			name: "base64 and quoted payloads",
+			desc: `B:base64:AAEC | B:" a | b " | B:"hex:00"`,
+			want: []*QrSegment{
+				mustSeg(MakeBytes([]byte{0, 1, 2})),
+				mustSeg(MakeBytes([]byte(" a | b "))),
+				mustSeg(MakeBytes([]byte("hex:00"))),
+			},
+		},
+		{
+			name: "empty",
+			desc: "  ",
+			want: []*QrSegment{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSegments(tt.desc)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseSegments_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		desc       string
+		wantOffset int
+		wantErr    error
+	}{
+		{"missing prefix", "N:1 | 23", 6, nil},
+		{"unknown prefix", "N:1 | X:23", 6, nil},
+		{"invalid numeric character", "N:1 | N:12a4", 10, ErrInvalidCharacter},
+		{"invalid alphanumeric character", "A:ABc", 4, ErrInvalidCharacter},
+		{"invalid kanji character", "K:点a", 5, ErrInvalidCharacter},
+		{"invalid hex", "B:hex:00ZZ", 8, nil},
+		{"invalid base64", "B:base64:AA*A", 11, nil},
+		{"invalid ECI", "ECI:x", 4, nil},
+		{"ECI out of range", "ECI:1000000", 4, ErrInvalidArgument},
+		{"unterminated quote", `N:1 | B:"abc`, 8, nil},
+		{"text after quoted payload", `B:"abc" d`, 8, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSegments(tt.desc)
+			var syntaxErr *SegmentSyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("ParseSegments() error = %v, want SegmentSyntaxError", err)
+			}
+			assert.Equal(t, tt.wantOffset, syntaxErr.Offset)
+			assert.ErrorIs(t, err, ErrInvalidArgument)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		desc string
+		want string
+	}{
+		{
+			name: "all modes",
+			desc: "N:0123456789 | A:HELLO WORLD | B:hex:DEADBEEF | K:点茗 | ECI:26",
+			want: "N:0123456789 | A:HELLO WORLD | B:hex:DEADBEEF | K:点茗 | ECI:26",
+		},
+		{
+			name: "text bytes",
+			desc: "b:hex:48656C6C6F | B:base64:4pyT",
+			want: "B:Hello | B:✓",
+		},
+		{
+			name: "quoting",
+			desc: `B:" a | b " | B:"hex:00" | N:7`,
+			want: `B:" a | b " | B:"hex:00" | N:7`,
+		},
+		{
+			name: "odd lengths and large ECI",
+			desc: "N:12345 | A:ABC | ECI:20000 | ECI:999999",
+			want: "N:12345 | A:ABC | ECI:20000 | ECI:999999",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segs, err := ParseSegments(tt.desc)
+			if err != nil {
+				t.Fatalf("ParseSegments() error = %v", err)
+			}
+			got, err := FormatSegments(segs)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			again, err := ParseSegments(got)
+			assert.NoError(t, err)
+			assert.Equal(t, segs, again)
+		})
+	}
+}
+
+func TestFormatSegments_MakeSegmentsOptimally(t *testing.T) {
+	segs, err := MakeSegmentsOptimally("12345678901234567890ABCDEFGHIJ点茗abc", Low, MinVersion, MaxVersion)
+	if err != nil {
+		t.Fatalf("MakeSegmentsOptimally() error = %v", err)
+	}
+	desc, err := FormatSegments(segs)
+	assert.NoError(t, err)
+
+	again, err := ParseSegments(desc)
+	assert.NoError(t, err)
+	assert.Equal(t, segs, again)
+}
+
+func TestFormatSegments_InvalidRawData(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     Mode
+		numChars int
+		val      int
+		bits     int
+	}{
+		{"three digits above 999", Numeric, 3, 1000, 10},
+		{"two digits above 99", Numeric, 2, 100, 7},
+		{"one digit above 9", Numeric, 1, 10, 4},
+		{"alphanumeric pair above 2024", Alphanumeric, 2, 0x7FF, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bits := &BitBuffer{}
+			if err := bits.AppendBits(tt.val, tt.bits); err != nil {
+				t.Fatalf("AppendBits() error = %v", err)
+			}
+			seg, err := NewRawSegment(tt.mode, tt.numChars, bits)
+			if err != nil {
+				t.Fatalf("NewRawSegment() error = %v", err)
+			}
+			_, err = FormatSegments([]*QrSegment{seg})
+			assert.ErrorIs(t, err, ErrInvalidArgument)
+		})
+	}
+}
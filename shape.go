@@ -0,0 +1,399 @@
+package go_qr
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// ModuleShape is the shape in which the dark modules of a QR code are drawn.
+type ModuleShape int
+
+const (
+	ShapeSquare         ModuleShape = iota // Squares filling the whole module, which is the default
+	ShapeCircle                            // Dots with the diameter of a module
+	ShapeRoundedSquare                     // Squares with rounded corners
+	ShapeDiamond                           // Squares rotated by 45 degrees, touching the middle of each side of the module
+	ShapeVerticalBars                      // Vertical bars with rounded ends, joining dark modules above each other
+	ShapeHorizontalBars                    // Horizontal bars with rounded ends, joining dark modules next to each other
+	ShapeConnected                         // Connected areas of dark modules, with only their outer corners rounded
+)
+
+// moduleShapeNames maps the ModuleShape to its name.
+var moduleShapeNames = [...]string{"square", "circle", "rounded square", "diamond", "vertical bars", "horizontal bars", "connected"}
+
+// String returns the name of the shape, for example "circle".
+func (s ModuleShape) String() string {
+	if s < ShapeSquare || s > ShapeConnected {
+		return "ModuleShape(" + strconv.Itoa(int(s)) + ")"
+	}
+	return moduleShapeNames[s]
+}
+
+const (
+	// defaultRoundedSquareRadius is the corner radius of ShapeRoundedSquare in modules.
+	defaultRoundedSquareRadius = 0.25
+	// defaultConnectedRadius is the corner radius of ShapeConnected in modules.
+	defaultConnectedRadius = 0.5
+	// barWidth is the width of the bars of ShapeVerticalBars and ShapeHorizontalBars in modules.
+	barWidth = 0.8
+)
+
+// WithModuleShape returns a function that sets the shape of the dark modules in PNG and SVG output
+// in the provided QrCodeImgConfig. The finder patterns stay square, unless WithStyledFinders is set.
+// SVG output draws ShapeConnected as outlines, and the other shapes one per module, whether WithOptimalSVG
+// is set or not.
+func WithModuleShape(shape ModuleShape) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.moduleShape = shape
+	}
+}
+
+// WithCornerRadius returns a function that sets the radius of the rounded corners of ShapeRoundedSquare
+// and ShapeConnected in the provided QrCodeImgConfig, as a fraction of the module width between 0 and 0.5.
+// A radius of 0 selects the default of 0.25 for rounded squares and 0.5 for connected areas.
+func WithCornerRadius(radius float64) func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.cornerRadius = radius
+	}
+}
+
+// WithStyledFinders returns a function that draws the finder patterns in the module shape as well
+// in the provided QrCodeImgConfig. By default they are kept solid, so that the QR code is found by scanners.
+func WithStyledFinders() func(*QrCodeImgConfig) {
+	return func(q *QrCodeImgConfig) {
+		q.options.styledFinders = true
+	}
+}
+
+// validShape checks the module shape and corner radius.
+func (q *QrCodeImgConfig) validShape() error {
+	if q.options.moduleShape < ShapeSquare || q.options.moduleShape > ShapeConnected {
+		return &ConfigError{Field: "moduleShape", Msg: "unknown module shape " + q.options.moduleShape.String()}
+	}
+	if r := q.options.cornerRadius; !(r >= 0 && r <= 0.5) {
+		return &ConfigError{Field: "cornerRadius", Msg: "corner radius must be between 0 and 0.5"}
+	}
+	return nil
+}
+
+// moduleStyle draws the dark modules of a QR code in the shape and the finder patterns in the styles
+// of QrCodeImgConfig.
+type moduleStyle struct {
+	qr      *QrCode
+	shape   ModuleShape
+	radius  float64 // Corner radius in modules.
+	solid   bool    // Whether the finder patterns are kept square.
+	scale   int
+	margins Margins // Quiet zone in modules.
+
+	finders       [finderCount]*FinderStyle
+	finderBoxes   [finderCount][3]roundedBox // Outside and inside of the ring, and eye of each styled finder pattern.
+	finderIndexes [finderCount][2]uint8      // Palette indexes of the ring and eye of each styled finder pattern.
+	palette       color.Palette              // Light, dark and finder pattern colors.
+	gradient      *gradient                  // Gradient of the dark modules, or nil.
+	colors        []color.Color              // Colors of ModuleColorFunc, after nil for the default color.
+	colorKeys     [][]int                    // Index in colors of the color of each module, or nil without ModuleColorFunc.
+	logo          *Logo                      // Logo drawn over the cleared modules, or nil.
+	halftone      [][]bool                   // Dark sub-pixels of WithHalftone, 3x3 per module, or nil.
+}
+
+// moduleStyle returns the style in which the QR code is drawn with QrCodeImgConfig.
+func (q *QrCode) moduleStyle(config *QrCodeImgConfig) *moduleStyle {
+	s := &moduleStyle{
+		qr:       q.withLogoCleared(config),
+		shape:    config.options.moduleShape,
+		radius:   config.options.cornerRadius,
+		solid:    !config.options.styledFinders,
+		scale:    config.scale,
+		margins:  config.quietZone(),
+		finders:  config.options.finderStyles,
+		gradient: config.options.gradient,
+		logo:     config.options.logo,
+	}
+	s.palette, s.finderIndexes = config.finderPalette()
+	for corner, style := range s.finders {
+		if style != nil {
+			outer, inner, eye := finderBoxes(FinderCorner(corner), style)
+			s.finderBoxes[corner] = [3]roundedBox{outer, inner, eye}
+		}
+	}
+	if f := config.options.moduleColorFunc; f != nil {
+		s.initModuleColors(f)
+	}
+	if img := config.options.halftone; img != nil {
+		s.halftone = newHalftone(s.qr, img, s.logo)
+	}
+	if s.radius == 0 {
+		switch s.shape {
+		case ShapeRoundedSquare:
+			s.radius = defaultRoundedSquareRadius
+		case ShapeConnected:
+			s.radius = defaultConnectedRadius
+		}
+	}
+	return s
+}
+
+// square reports whether every dark module is drawn as a full square.
+func (s *moduleStyle) square() bool {
+	return s.shape == ShapeSquare && s.finders == [finderCount]*FinderStyle{} && s.halftone == nil
+}
+
+// finderStyled reports whether the module at (x, y) belongs to a finder pattern which is drawn in its own style.
+func (s *moduleStyle) finderStyled(x, y int) bool {
+	corner, _, _, ok := s.qr.finderAt(x, y)
+	return ok && s.finders[corner] != nil
+}
+
+// keepsSquare reports whether the module at (x, y) is drawn as a square whatever the shape,
+// because it belongs to a finder pattern which is kept solid.
+func (s *moduleStyle) keepsSquare(x, y int) bool {
+	if !s.solid {
+		return false
+	}
+	size := s.qr.size
+	return (x < 7 || x >= size-7) && y < 7 || x < 7 && y >= size-7
+}
+
+// joins reports whether the module at (nx, ny) is a dark module which joins its neighbour at (x, y) in a bar,
+// which it does if both have the same color.
+func (s *moduleStyle) joins(x, y, nx, ny int) bool {
+	return s.qr.GetModule(nx, ny) && !s.keepsSquare(nx, ny) && s.colorKey(x, y) == s.colorKey(nx, ny)
+}
+
+// covers reports whether the point (fx, fy) of the module at (x, y) is dark,
+// where fx and fy are between 0 and 1 from the top left corner of the module.
+func (s *moduleStyle) covers(x, y int, fx, fy float64) bool {
+	if !s.qr.GetModule(x, y) {
+		return false
+	}
+	if s.shape == ShapeSquare || s.keepsSquare(x, y) {
+		return true
+	}
+
+	dx, dy := fx-0.5, fy-0.5
+	switch s.shape {
+	case ShapeCircle:
+		return dx*dx+dy*dy <= 0.25
+	case ShapeDiamond:
+		return math.Abs(dx)+math.Abs(dy) <= 0.5
+	case ShapeRoundedSquare:
+		return insideRoundedCorners(fx, fy, s.radius, true, true, true, true)
+	case ShapeVerticalBars:
+		return insideBar(dx, dy, s.joins(x, y, x, y-1), s.joins(x, y, x, y+1))
+	case ShapeHorizontalBars:
+		return insideBar(dy, dx, s.joins(x, y, x-1, y), s.joins(x, y, x+1, y))
+	case ShapeConnected:
+		// A corner is an outer corner of the connected area if both modules next to it are light.
+		up, right, down, left := s.qr.GetModule(x, y-1), s.qr.GetModule(x+1, y), s.qr.GetModule(x, y+1), s.qr.GetModule(x-1, y)
+		return insideRoundedCorners(fx, fy, s.radius, !up && !left, !up && !right, !down && !right, !down && !left)
+	}
+	return true
+}
+
+// indexAt returns the palette index of the point (fx, fy) of the module at (x, y),
+// where fx and fy are between 0 and 1 from the top left corner of the module.
+func (s *moduleStyle) indexAt(x, y int, fx, fy float64) uint8 {
+	if s.halftone != nil {
+		if s.halftoneDark(x, y, fx, fy) {
+			return darkIndex
+		}
+		return lightIndex
+	}
+	if corner, cx, cy, ok := s.qr.finderAt(x, y); ok && s.finders[corner] != nil {
+		px, py := float64(cx)+fx, float64(cy)+fy
+		boxes := s.finderBoxes[corner]
+		switch {
+		case boxes[2].contains(px, py):
+			return s.finderIndexes[corner][1]
+		case boxes[0].contains(px, py) && !boxes[1].contains(px, py):
+			return s.finderIndexes[corner][0]
+		}
+		return lightIndex
+	}
+	if s.covers(x, y, fx, fy) {
+		return darkIndex
+	}
+	return lightIndex
+}
+
+// pixelIndex returns the palette index of the pixel at (x, y) of an image with the scale and quiet zone
+// of the style. The pixel is sampled at its center.
+func (s *moduleStyle) pixelIndex(x, y int) uint8 {
+	moduleX, moduleY := x/s.scale-s.margins.Left, y/s.scale-s.margins.Top
+	if s.square() {
+		if s.qr.GetModule(moduleX, moduleY) {
+			return darkIndex
+		}
+		return lightIndex
+	}
+	fx := (float64(x%s.scale) + 0.5) / float64(s.scale)
+	fy := (float64(y%s.scale) + 0.5) / float64(s.scale)
+	return s.indexAt(moduleX, moduleY, fx, fy)
+}
+
+// rowsPerModule returns the number of pixel rows which are the same in each module row of an image with
+// the scale of the style, so that writers only compute one of them.
+func (s *moduleStyle) rowsPerModule() int {
+	if s.square() && s.gradient == nil && (s.logo == nil || s.logo.Image == nil) {
+		return s.scale
+	}
+	return 1
+}
+
+// gradientColor returns the color of the gradient at the point (px, py) in pixels of an image with the scale
+// and quiet zone of the style.
+func (s *moduleStyle) gradientColor(px, py float64) color.NRGBA {
+	size := float64(s.qr.size * s.scale)
+	u := (px - float64(s.margins.Left*s.scale)) / size
+	v := (py - float64(s.margins.Top*s.scale)) / size
+	return s.gradient.colorAt(s.gradient.offsetAt(u, v))
+}
+
+// insideBar reports whether the point (across, along), relative to the center of a module, is inside
+// a bar running along the module, which continues to the previous or next module if joined.
+func insideBar(across, along float64, joinsPrev, joinsNext bool) bool {
+	r := barWidth / 2
+	if math.Abs(across) > r {
+		return false
+	}
+	if along < 0 && joinsPrev || along > 0 && joinsNext {
+		return true
+	}
+	return across*across+along*along <= r*r
+}
+
+// insideRoundedCorners reports whether the point (fx, fy) of a module is inside the module
+// with the given corners rounded by radius r.
+func insideRoundedCorners(fx, fy, r float64, topLeft, topRight, bottomRight, bottomLeft bool) bool {
+	var cx, cy float64
+	rounded := false
+	switch {
+	case fx < r && fy < r:
+		cx, cy, rounded = r, r, topLeft
+	case fx > 1-r && fy < r:
+		cx, cy, rounded = 1-r, r, topRight
+	case fx > 1-r && fy > 1-r:
+		cx, cy, rounded = 1-r, 1-r, bottomRight
+	case fx < r && fy > 1-r:
+		cx, cy, rounded = r, 1-r, bottomLeft
+	}
+	return !rounded || (fx-cx)*(fx-cx)+(fy-cy)*(fy-cy) <= r*r
+}
+
+// writeModulePath writes the path data of the dark module at (x, y), whose top left corner is at (px, py)
+// in the image, preceded by sep. A bar is written whole at its first module, and nothing at the others.
+// It reports whether anything was written.
+func (s *moduleStyle) writeModulePath(sw *svgWriter, sep string, x, y, px, py int) bool {
+	scale := float64(s.scale)
+	left, top := float64(px), float64(py)
+	switch s.shape {
+	case ShapeCircle:
+		r := scale / 2
+		sw.writeString(sep + "M")
+		sw.writeFloat(left)
+		sw.writeString(",")
+		sw.writeFloat(top + r)
+		sw.writeString("a")
+		sw.writeFloat(r)
+		sw.writeString(",")
+		sw.writeFloat(r)
+		sw.writeString(" 0 1 0 ")
+		sw.writeFloat(2 * r)
+		sw.writeString(",0a")
+		sw.writeFloat(r)
+		sw.writeString(",")
+		sw.writeFloat(r)
+		sw.writeString(" 0 1 0 ")
+		sw.writeFloat(-2 * r)
+		sw.writeString(",0z")
+	case ShapeDiamond:
+		half := scale / 2
+		sw.writeString(sep + "M")
+		sw.writeFloat(left + half)
+		sw.writeString(",")
+		sw.writeFloat(top)
+		sw.writeString("l")
+		sw.writeFloat(half)
+		sw.writeString(",")
+		sw.writeFloat(half)
+		sw.writeString(" ")
+		sw.writeFloat(-half)
+		sw.writeString(",")
+		sw.writeFloat(half)
+		sw.writeString(" ")
+		sw.writeFloat(-half)
+		sw.writeString(",")
+		sw.writeFloat(-half)
+		sw.writeString("z")
+	case ShapeRoundedSquare:
+		sw.writeString(sep)
+		writeRoundedRect(sw, left, top, scale, scale, s.radius*scale)
+	case ShapeVerticalBars, ShapeHorizontalBars:
+		dx, dy := 0, 1
+		if s.shape == ShapeHorizontalBars {
+			dx, dy = 1, 0
+		}
+		if s.joins(x, y, x-dx, y-dy) {
+			return false
+		}
+		n := 1
+		for s.joins(x, y, x+n*dx, y+n*dy) {
+			n++
+		}
+		inset := (1 - barWidth) / 2 * scale
+		length := float64(n)*scale - 2*inset
+		sw.writeString(sep)
+		if dy == 1 {
+			writeRoundedRect(sw, left+inset, top+inset, barWidth*scale, length, barWidth/2*scale)
+		} else {
+			writeRoundedRect(sw, left+inset, top+inset, length, barWidth*scale, barWidth/2*scale)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// writeRoundedRect writes the path data of a rectangle with corners rounded by radius r.
+func writeRoundedRect(sw *svgWriter, x, y, w, h, r float64) {
+	writeRoundedBox(sw, roundedBox{x: x, y: y, w: w, h: h, radii: [4]float64{r, r, r, r}})
+}
+
+// writeRoundedBox writes the path data of the box, clockwise from the top left corner.
+func writeRoundedBox(sw *svgWriter, b roundedBox) {
+	r := b.radii
+	arc := func(r, toX, toY float64) {
+		if r > 0 {
+			sw.writeString("A")
+			sw.writeFloat(r)
+			sw.writeString(",")
+			sw.writeFloat(r)
+			sw.writeString(" 0 0 1 ")
+			sw.writeFloat(toX)
+			sw.writeString(",")
+			sw.writeFloat(toY)
+		}
+	}
+	// Lines between the arcs are left out where the arcs meet.
+	line := func(cmd string, to, length, r1, r2 float64) {
+		if length > r1+r2 {
+			sw.writeString(cmd)
+			sw.writeFloat(to)
+		}
+	}
+	sw.writeString("M")
+	sw.writeFloat(b.x + r[0])
+	sw.writeString(",")
+	sw.writeFloat(b.y)
+	line("H", b.x+b.w-r[1], b.w, r[0], r[1])
+	arc(r[1], b.x+b.w, b.y+r[1])
+	line("V", b.y+b.h-r[2], b.h, r[1], r[2])
+	arc(r[2], b.x+b.w-r[2], b.y+b.h)
+	line("H", b.x+r[3], b.w, r[2], r[3])
+	arc(r[3], b.x, b.y+b.h-r[3])
+	line("V", b.y+r[0], b.h, r[3], r[0])
+	arc(r[0], b.x+r[0], b.y)
+	sw.writeString("z")
+}
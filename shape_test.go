@@ -0,0 +1,185 @@
+package go_qr
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var allShapes = []ModuleShape{ShapeSquare, ShapeCircle, ShapeRoundedSquare, ShapeDiamond, ShapeVerticalBars, ShapeHorizontalBars, ShapeConnected}
+
+func TestModuleShape_String(t *testing.T) {
+	assert.Equal(t, "square", ShapeSquare.String())
+	assert.Equal(t, "vertical bars", ShapeVerticalBars.String())
+	assert.Equal(t, "connected", ShapeConnected.String())
+	assert.Equal(t, "ModuleShape(7)", ModuleShape(7).String())
+}
+
+func TestQrCodeImgConfig_validShape(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []func(*QrCodeImgConfig)
+		wantErr bool
+	}{
+		{"default", nil, false},
+		{"circle", []func(*QrCodeImgConfig){WithModuleShape(ShapeCircle)}, false},
+		{"radius", []func(*QrCodeImgConfig){WithModuleShape(ShapeRoundedSquare), WithCornerRadius(0.5)}, false},
+		{"unknown shape", []func(*QrCodeImgConfig){WithModuleShape(ModuleShape(-1))}, true},
+		{"radius too large", []func(*QrCodeImgConfig){WithCornerRadius(0.6)}, true},
+		{"negative radius", []func(*QrCodeImgConfig){WithCornerRadius(-0.1)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewQrCodeImgConfig(10, 4, tt.options...).Valid()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			if err != nil {
+				assert.True(t, errors.Is(err, ErrInvalidConfig))
+			}
+		})
+	}
+}
+
+func TestQrCode_WriteAsPNG_Shapes(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	const scale = 10
+	isDark := func(c color.Color) bool {
+		r, _, _, _ := c.RGBA()
+		return r == 0
+	}
+	for _, shape := range allShapes {
+		t.Run(shape.String(), func(t *testing.T) {
+			config := NewQrCodeImgConfig(scale, 0, WithModuleShape(shape))
+			var buf bytes.Buffer
+			assert.NoError(t, qr.WriteAsPNG(config, &buf))
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("png.Decode() error = %v", err)
+			}
+
+			view, err := qr.Image(config)
+			assert.NoError(t, err)
+			for y := 0; y < qr.GetSize()*scale; y++ {
+				for x := 0; x < qr.GetSize()*scale; x++ {
+					if isDark(img.At(x, y)) != (view.ColorIndexAt(x, y) == darkIndex) {
+						t.Fatalf("pixel (%d, %d) of the PNG and the image differ", x, y)
+					}
+				}
+			}
+
+			for y := 0; y < qr.GetSize(); y++ {
+				for x := 0; x < qr.GetSize(); x++ {
+					center := isDark(img.At(x*scale+scale/2, y*scale+scale/2))
+					assert.Equal(t, qr.GetModule(x, y), center, "center of module (%d, %d)", x, y)
+				}
+			}
+
+			// The finder patterns are kept solid.
+			assert.True(t, isDark(img.At(0, 0)))
+			assert.True(t, isDark(img.At(qr.GetSize()*scale-1, 0)))
+		})
+	}
+}
+
+func TestQrCode_WriteAsPNG_ShapeCorners(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	// Corner pixels of the top left finder pattern: its outer corner, and the inner corner of its ring at (1, 1).
+	tests := []struct {
+		shape            ModuleShape
+		outer, ringInner bool
+	}{
+		{ShapeSquare, true, true},
+		{ShapeCircle, false, false},
+		{ShapeRoundedSquare, false, false},
+		{ShapeDiamond, false, false},
+		{ShapeVerticalBars, false, false},
+		{ShapeConnected, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shape.String(), func(t *testing.T) {
+			img, err := qr.Image(NewQrCodeImgConfig(10, 0, WithModuleShape(tt.shape), WithStyledFinders()))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.outer, img.ColorIndexAt(0, 0) == darkIndex)
+			// The pixel left of and above the light module at (1, 1), inside the dark module at (0, 0).
+			assert.Equal(t, tt.ringInner, img.ColorIndexAt(9, 9) == darkIndex)
+		})
+	}
+}
+
+func TestQrCode_SVGString_Shapes(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	tests := []struct {
+		shape       ModuleShape
+		options     []func(*QrCodeImgConfig)
+		contains    []string
+		notContains []string
+	}{
+		{
+			shape:    ShapeSquare,
+			contains: []string{"M0,0h10v10h-10z M10,0h10v10h-10z"},
+		},
+		{
+			shape:    ShapeCircle,
+			contains: []string{"M0,0h10v10h-10z", "M120,5a5,5 0 1 0 10,0a5,5 0 1 0 -10,0z"},
+		},
+		{
+			shape:    ShapeDiamond,
+			contains: []string{"M125,0l5,5 -5,5 -5,-5z"},
+		},
+		{
+			shape:    ShapeRoundedSquare,
+			options:  []func(*QrCodeImgConfig){WithCornerRadius(0.2)},
+			contains: []string{"M122,0H128A2,2 0 0 1 130,2V8A2,2 0 0 1 128,10H122A2,2 0 0 1 120,8V2A2,2 0 0 1 122,0z"},
+		},
+		{
+			shape:    ShapeVerticalBars,
+			contains: []string{"M125,1A4,4 0 0 1 129,5A4,4 0 0 1 125,9A4,4 0 0 1 121,5A4,4 0 0 1 125,1z"},
+		},
+		{
+			shape:       ShapeConnected,
+			contains:    []string{"<path d=\"M0,0H70V70H0z", "M125,0A5,5 0 0 1 130,5A5,5 0 0 1 125,10A5,5 0 0 1 120,5A5,5 0 0 1 125,0z"},
+			notContains: []string{"h", "v"},
+		},
+		{
+			shape:       ShapeConnected,
+			options:     []func(*QrCodeImgConfig){WithStyledFinders(), WithCornerRadius(0.3)},
+			contains:    []string{"<path d=\"M3,0H67A3,3 0 0 1 70,3V67A3,3 0 0 1 67,70H3A3,3 0 0 1 0,67V3A3,3 0 0 1 3,0z"},
+			notContains: []string{"M0,0H70"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shape.String(), func(t *testing.T) {
+			config := NewQrCodeImgConfig(10, 0, append([]func(*QrCodeImgConfig){WithModuleShape(tt.shape)}, tt.options...)...)
+			svg, err := qr.SVGString(config)
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, svg, s)
+			}
+			path := svg[strings.Index(svg, "<path d=\"")+len("<path d=\""):]
+			path = path[:strings.Index(path, "\"")]
+			for _, s := range tt.notContains {
+				assert.NotContains(t, path, s)
+			}
+
+			again, err := qr.SVGString(config)
+			assert.NoError(t, err)
+			assert.Equal(t, svg, again)
+		})
+	}
+}
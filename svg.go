@@ -0,0 +1,185 @@
+package go_qr
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// svgPaint is a color of SVG output: a CSS color and its opacity, or a CSS class.
+type svgPaint struct {
+	color   string // CSS color, or empty for no paint.
+	opacity string // Opacity between 0 and 1, or empty if the color is opaque.
+	class   string // CSS class which styles the paint, replacing the color.
+}
+
+// newSVGPaint converts a color.Color to a hex CSS color, with its alpha as opacity.
+// A fully transparent color is no paint.
+func newSVGPaint(c color.Color) svgPaint {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A == 0 {
+		return svgPaint{}
+	}
+
+	p := svgPaint{color: fmt.Sprintf("#%02X%02X%02X", nrgba.R, nrgba.G, nrgba.B)}
+	if nrgba.A != 0xFF {
+		p.opacity = strconv.FormatFloat(math.Round(float64(nrgba.A)/0xFF*1000)/1000, 'f', -1, 64)
+	}
+	return p
+}
+
+// visible reports whether the paint draws anything.
+func (p svgPaint) visible() bool {
+	return p.color != "" || p.class != ""
+}
+
+// attrs returns the class, or the fill and fill-opacity attributes of the paint, starting with a space.
+func (p svgPaint) attrs() string {
+	switch {
+	case p.class != "":
+		return fmt.Sprintf(` class="%s"`, svgAttrEscaper.Replace(p.class))
+	case p.color == "":
+		return ` fill="none"`
+	case p.opacity == "":
+		return fmt.Sprintf(` fill="%s"`, svgAttrEscaper.Replace(p.color))
+	}
+	return fmt.Sprintf(` fill="%s" fill-opacity="%s"`, svgAttrEscaper.Replace(p.color), p.opacity)
+}
+
+// svgAttrEscaper escapes text for use in a double-quoted XML attribute or in character data.
+var svgAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// svgText makes text valid in an XML document and escapes it, by replacing invalid UTF-8
+// and removing the control characters which XML does not allow.
+func svgText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' || r == 0xFFFE || r == 0xFFFF {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(text, "\uFFFD"))
+	return svgAttrEscaper.Replace(text)
+}
+
+// svgPaints returns the light and dark paint of SVG output: the CSS classes set by WithSVGClasses,
+// else the CSS colors set by WithSVGColors, else the light color of QrCodeImgConfig and its gradient
+// or dark color.
+func (q *QrCodeImgConfig) svgPaints() (light, dark svgPaint) {
+	if q.options.svgClasses != nil {
+		return svgPaint{class: q.options.svgClasses[0]}, svgPaint{class: q.options.svgClasses[1]}
+	}
+	if q.options.svgColors != nil {
+		return svgPaint{color: q.options.svgColors[0]}, svgPaint{color: q.options.svgColors[1]}
+	}
+	if q.options.gradient != nil {
+		return newSVGPaint(q.Light()), svgPaint{color: "url(#" + q.svgGradientID() + ")"}
+	}
+	return newSVGPaint(q.Light()), newSVGPaint(q.Dark())
+}
+
+// svgWriter writes SVG output through a buffer. After the first write error, later writes do nothing,
+// and the error is kept in err, so that renderers only need to check it once per row.
+type svgWriter struct {
+	w       *bufio.Writer
+	scratch []byte
+	err     error
+}
+
+// newSVGWriter returns a svgWriter which writes to w.
+func newSVGWriter(w io.Writer) *svgWriter {
+	return &svgWriter{w: bufio.NewWriter(w), scratch: make([]byte, 0, 20)}
+}
+
+// writeString writes str, unless an earlier write failed.
+func (s *svgWriter) writeString(str string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(str)
+	}
+}
+
+// writeInt writes n in decimal, unless an earlier write failed.
+func (s *svgWriter) writeInt(n int) {
+	if s.err == nil {
+		s.scratch = strconv.AppendInt(s.scratch[:0], int64(n), 10)
+		_, s.err = s.w.Write(s.scratch)
+	}
+}
+
+// writeFloat writes v rounded to 3 decimals, unless an earlier write failed.
+func (s *svgWriter) writeFloat(v float64) {
+	if s.err == nil {
+		s.scratch = strconv.AppendFloat(s.scratch[:0], math.Round(v*1000)/1000, 'f', -1, 64)
+		_, s.err = s.w.Write(s.scratch)
+	}
+}
+
+// printf writes formatted text, unless an earlier write failed.
+func (s *svgWriter) printf(format string, a ...interface{}) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, format, a...)
+	}
+}
+
+// flush writes the buffered output, and returns the first write error.
+func (s *svgWriter) flush() error {
+	if s.err == nil {
+		s.err = s.w.Flush()
+	}
+	return s.err
+}
+
+// writeSVGStart writes the XML header if QrCodeImgConfig asks for it, the start tag of the svg element
+// for an image of the given size in pixels with the extra attributes attrs, the title and desc elements,
+// and the definition of the gradient.
+func (q *QrCode) writeSVGStart(sw *svgWriter, config *QrCodeImgConfig, width, height int, attrs string) error {
+	options := config.options
+	var description string
+	// The default description contains the payload, unless it cannot be decoded as text.
+	if options.svgDesc != nil && *options.svgDesc == "" || options.svgAriaLabel != nil && *options.svgAriaLabel == "" {
+		description = "QR code"
+		if payload, err := q.decodeText(); err == nil && payload != "" {
+			description += ": " + payload
+		}
+	}
+	orDefault := func(text *string, def string) string {
+		if *text == "" {
+			return svgText(def)
+		}
+		return svgText(*text)
+	}
+
+	if options.svgXMLHeader {
+		sw.writeString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+		sw.writeString("<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd\">\n")
+	}
+	sw.writeString("<svg xmlns=\"http://www.w3.org/2000/svg\" version=\"1.1\"")
+	if options.svgID != "" {
+		sw.printf(" id=\"%s\"", svgText(options.svgID))
+	}
+	if options.svgClass != "" {
+		sw.printf(" class=\"%s\"", svgText(options.svgClass))
+	}
+	if options.svgAriaLabel != nil {
+		sw.printf(" role=\"img\" aria-label=\"%s\"", orDefault(options.svgAriaLabel, description))
+	}
+	sw.printf("%s viewBox=\"0 0 %d %d\" stroke=\"none\"", svgSizeAttributes(config, width, height), width, height)
+	if options.svgCrisp {
+		sw.writeString(" shape-rendering=\"crispEdges\"")
+	}
+	sw.writeString(attrs + ">\n")
+	if options.svgTitle != nil {
+		sw.printf("\t<title>%s</title>\n", orDefault(options.svgTitle, "QR code"))
+	}
+	if options.svgDesc != nil {
+		sw.printf("\t<desc>%s</desc>\n", orDefault(options.svgDesc, description))
+	}
+	if g := config.svgGradient(); g != nil {
+		m, _, _, _ := config.svgCanvas(q.size)
+		g.writeSVGDefs(sw, config.svgGradientID(), float64(m.Left), float64(m.Top), float64(q.size*config.scale))
+	}
+	return nil
+}
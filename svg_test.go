@@ -0,0 +1,255 @@
+package go_qr
+
+import (
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSVGPaint(t *testing.T) {
+	tests := []struct {
+		name  string
+		color color.Color
+		want  svgPaint
+		fill  string
+	}{
+		{"white", color.White, svgPaint{color: "#FFFFFF"}, ` fill="#FFFFFF"`},
+		{"black", color.Black, svgPaint{color: "#000000"}, ` fill="#000000"`},
+		{"opaque color", color.RGBA{R: 0x12, G: 0xAB, B: 0x3C, A: 0xFF}, svgPaint{color: "#12AB3C"}, ` fill="#12AB3C"`},
+		{"translucent color", color.NRGBA{R: 0xFF, A: 0x80}, svgPaint{color: "#FF0000", opacity: "0.502"}, ` fill="#FF0000" fill-opacity="0.502"`},
+		{"premultiplied translucent color", color.RGBA{R: 0x40, A: 0x80}, svgPaint{color: "#7F0000", opacity: "0.502"}, ` fill="#7F0000" fill-opacity="0.502"`},
+		{"transparent", color.Transparent, svgPaint{}, ` fill="none"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newSVGPaint(tt.color)
+			assert.Equal(t, tt.want, p)
+			assert.Equal(t, tt.fill, p.attrs())
+		})
+	}
+}
+
+func TestQrCode_SVGString_Colors(t *testing.T) {
+	qr, err := EncodeText("Hello, world!", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		options     []func(*QrCodeImgConfig)
+		light, dark color.Color
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:     "default colors",
+			light:    color.White,
+			dark:     color.Black,
+			contains: []string{`<rect width="210" height="210" fill="#FFFFFF"/>`, `" fill="#000000"/>`},
+		},
+		{
+			name:     "translucent dark color",
+			light:    color.White,
+			dark:     color.NRGBA{B: 0xFF, A: 0x33},
+			contains: []string{`" fill="#0000FF" fill-opacity="0.2"/>`},
+		},
+		{
+			name:        "transparent light color",
+			light:       color.Transparent,
+			dark:        color.Black,
+			notContains: []string{"<rect"},
+		},
+		{
+			name:        "optimized transparent light color",
+			options:     []func(*QrCodeImgConfig){WithOptimalSVG()},
+			light:       color.Transparent,
+			dark:        color.RGBA{R: 0xFF, A: 0xFF},
+			contains:    []string{`" fill="#FF0000"/>`},
+			notContains: []string{"<rect", "style=\"fill:"},
+		},
+		{
+			name:     "CSS colors",
+			options:  []func(*QrCodeImgConfig){WithSVGColors("transparent", "var(--qr-dark, #000)")},
+			light:    color.White,
+			dark:     color.Black,
+			contains: []string{`fill="transparent"/>`, `" fill="var(--qr-dark, #000)"/>`},
+		},
+		{
+			name:        "CSS colors without background",
+			options:     []func(*QrCodeImgConfig){WithOptimalSVG(), WithSVGColors("", "currentColor")},
+			light:       color.White,
+			dark:        color.Black,
+			contains:    []string{`" fill="currentColor"/>`},
+			notContains: []string{"<rect"},
+		},
+		{
+			name:     "CSS colors are escaped",
+			options:  []func(*QrCodeImgConfig){WithSVGColors("white", `url("#a")`)},
+			light:    color.White,
+			dark:     color.Black,
+			contains: []string{`" fill="url(&quot;#a&quot;)"/>`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewQrCodeImgConfig(10, 0, tt.options...)
+			config.SetLight(tt.light)
+			config.SetDark(tt.dark)
+
+			svg, err := qr.SVGString(config)
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, svg, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, svg, s)
+			}
+		})
+	}
+}
+
+// limitWriter fails once more than limit bytes are written, and counts the calls to Write.
+type limitWriter struct {
+	limit, written, calls, failedCalls int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if w.written+len(p) > w.limit {
+		w.failedCalls++
+		return 0, errors.New("limit reached")
+	}
+	w.written += len(p)
+	return len(p), nil
+}
+
+func TestQrCode_WriteAsSVG_Streaming(t *testing.T) {
+	qr, err := EncodeText(strings.Repeat("0123456789", 700), Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	assert.Equal(t, 40, qr.GetVersion())
+
+	for _, optimal := range []bool{false, true} {
+		config := NewQrCodeImgConfig(10, 4)
+		if optimal {
+			WithOptimalSVG()(config)
+		}
+
+		svg, err := qr.SVGString(config)
+		assert.NoError(t, err)
+
+		counter := &limitWriter{limit: len(svg)}
+		assert.NoError(t, qr.WriteAsSVG(config, counter))
+		assert.Equal(t, len(svg), counter.written)
+		assert.Less(t, counter.calls, len(svg)/1000, "output is not buffered")
+
+		failing := &limitWriter{limit: 10000}
+		err = qr.WriteAsSVG(config, failing)
+		assert.ErrorContains(t, err, "limit reached")
+		assert.Equal(t, 1, failing.failedCalls, "writing continued after an error")
+	}
+}
+
+func TestQrCode_SVGString_Attributes(t *testing.T) {
+	qr, err := EncodeText("https://example.com/?a=1&b=<2>", Low)
+	if err != nil {
+		t.Fatalf("EncodeText() error = %v", err)
+	}
+	description := "QR code: https://example.com/?a=1&amp;b=&lt;2&gt;"
+
+	tests := []struct {
+		name        string
+		options     []func(*QrCodeImgConfig)
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:        "no options",
+			notContains: []string{"<title>", "<desc>", "role=", "aria-label=", " id=", "class=", "shape-rendering=", `version="1.1" width=`},
+		},
+		{
+			name:     "default title and description",
+			options:  []func(*QrCodeImgConfig){WithSVGTitle(""), WithSVGDescription("")},
+			contains: []string{"\t<title>QR code</title>\n\t<desc>" + description + "</desc>\n"},
+		},
+		{
+			name:     "title and description",
+			options:  []func(*QrCodeImgConfig){WithSVGTitle("Our <site>"), WithSVGDescription("Scan\x00 me")},
+			contains: []string{"<title>Our &lt;site&gt;</title>", "<desc>Scan me</desc>"},
+		},
+		{
+			name:     "default aria label",
+			options:  []func(*QrCodeImgConfig){WithSVGAriaLabel("")},
+			contains: []string{` role="img" aria-label="` + description + `"`},
+		},
+		{
+			name:     "aria label",
+			options:  []func(*QrCodeImgConfig){WithSVGAriaLabel(`Link to "example"`)},
+			contains: []string{` role="img" aria-label="Link to &quot;example&quot;"`},
+		},
+		{
+			name:     "id and class",
+			options:  []func(*QrCodeImgConfig){WithSVGID("qr-1"), WithSVGClass("qr large")},
+			contains: []string{`<svg xmlns="http://www.w3.org/2000/svg" version="1.1" id="qr-1" class="qr large" viewBox=`},
+		},
+		{
+			name:        "path classes",
+			options:     []func(*QrCodeImgConfig){WithSVGClasses("qr-light", "qr-dark")},
+			contains:    []string{`<rect width="250" height="250" class="qr-light"/>`, `" class="qr-dark"/>`},
+			notContains: []string{"fill="},
+		},
+		{
+			name:        "optimized path classes without background",
+			options:     []func(*QrCodeImgConfig){WithOptimalSVG(), WithSVGClasses("", "qr-dark")},
+			contains:    []string{`" class="qr-dark"/>`},
+			notContains: []string{"<rect", "fill="},
+		},
+		{
+			name:     "crisp edges",
+			options:  []func(*QrCodeImgConfig){WithSVGCrispEdges()},
+			contains: []string{`viewBox="0 0 250 250" stroke="none" shape-rendering="crispEdges">`},
+		},
+		{
+			name:     "optimized crisp edges",
+			options:  []func(*QrCodeImgConfig){WithOptimalSVG(), WithSVGCrispEdges()},
+			contains: []string{`stroke="none" shape-rendering="crispEdges" style="fill-rule:evenodd;clip-rule:evenodd">`},
+		},
+		{
+			name:     "size in pixels",
+			options:  []func(*QrCodeImgConfig){WithSVGSize("", "")},
+			contains: []string{` width="250" height="250" viewBox="0 0 250 250"`},
+		},
+		{
+			name:     "size replaces print size",
+			options:  []func(*QrCodeImgConfig){WithSVGSize("100%", ""), WithPrintSize(PrintSize{Width: 30, Unit: Millimeter, DPI: 300, QuietZone: 4})},
+			contains: []string{` width="100%" height="`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svg, err := qr.SVGString(NewQrCodeImgConfig(10, 0, tt.options...))
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, svg, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, svg, s)
+			}
+		})
+	}
+}
+
+func TestQrCode_SVGString_UndecodablePayload(t *testing.T) {
+	qr := undecodableQrCode(t)
+
+	// Accessibility text never fails the SVG, and the description falls back to the plain default.
+	svg, err := qr.SVGString(NewQrCodeImgConfig(10, 4, WithSVGTitle(""), WithSVGDescription(""), WithSVGAriaLabel("")))
+	assert.NoError(t, err)
+	assert.Contains(t, svg, "\t<title>QR code</title>\n\t<desc>QR code</desc>\n")
+	assert.Contains(t, svg, ` role="img" aria-label="QR code"`)
+}
@@ -0,0 +1,117 @@
+package go_qr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// truncateOptions holds the configuration of TruncateToFit.
+type truncateOptions struct {
+	// ellipsis is appended to the text whenever it is truncated.
+	ellipsis string
+	// urlSafe prevents the cut from leaving a broken percent-escape or a dangling separator.
+	urlSafe bool
+}
+
+// TruncateOption configures how TruncateToFit shortens a text.
+type TruncateOption func(*truncateOptions)
+
+// WithEllipsis returns a TruncateOption that appends ellipsis (for example "…")
+// to the text whenever it has to be truncated. The ellipsis is included when checking the capacity.
+func WithEllipsis(ellipsis string) TruncateOption {
+	return func(o *truncateOptions) {
+		o.ellipsis = ellipsis
+	}
+}
+
+// WithURLSafeTruncation returns a TruncateOption for texts that are URLs.
+// The cut never splits a percent-escape such as "%2F", and trailing '?', '&' and '#'
+// separators left by the cut are removed.
+func WithURLSafeTruncation() TruncateOption {
+	return func(o *truncateOptions) {
+		o.urlSafe = true
+	}
+}
+
+// TruncateToFit returns the longest prefix of text which fits in a QR code of at most maxVersion
+// at the error correction level ecl, together with the optimal segments encoding it.
+// If text fits as a whole, it is returned unchanged.
+// The cut is always made between two characters, so UTF-8 sequences and Kanji characters are never split,
+// and the segments are computed again for the prefix so that no segment is cut in the middle.
+// It returns a DataTooLongException if not even the empty prefix (plus ellipsis) fits.
+func TruncateToFit(text string, ecl Ecc, maxVersion int, options ...TruncateOption) (string, []*QrSegment, error) {
+	if !isValidVersion(MinVersion, maxVersion) {
+		return "", nil, ErrInvalidVersion
+	}
+
+	if !utf8.ValidString(text) {
+		return "", nil, fmt.Errorf("%w: invalid UTF-8 string", ErrInvalidArgument)
+	}
+
+	opts := &truncateOptions{}
+	for _, o := range options {
+		o(opts)
+	}
+
+	segs, err := fitSegments(text, ecl, maxVersion)
+	if err != nil || segs != nil {
+		return text, segs, err
+	}
+
+	// Binary search for the longest prefix which fits, counted in runes.
+	runes := []rune(text)
+	lo, hi := 0, len(runes)
+	for lo+1 < hi {
+		mid := (lo + hi) / 2
+		segs, err = fitSegments(string(runes[:mid])+opts.ellipsis, ecl, maxVersion)
+		if err != nil {
+			return "", nil, err
+		}
+		if segs != nil {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	prefix := string(runes[:lo])
+	if opts.urlSafe {
+		prefix = trimURLCut(prefix)
+	}
+
+	prefix += opts.ellipsis
+	if prefix == "" {
+		return "", []*QrSegment{}, nil
+	}
+	segs, err = MakeSegmentsOptimally(prefix, ecl, MinVersion, maxVersion)
+	if err != nil {
+		return "", nil, err
+	}
+	return prefix, segs, nil
+}
+
+// fitSegments makes the optimal segments for text in a QR code of at most maxVersion.
+// It returns nil segments and no error if the text does not fit.
+func fitSegments(text string, ecl Ecc, maxVersion int) ([]*QrSegment, error) {
+	if text == "" {
+		return []*QrSegment{}, nil
+	}
+
+	segs, err := MakeSegmentsOptimally(text, ecl, MinVersion, maxVersion)
+	var dataTooLong *DataTooLongException
+	if errors.As(err, &dataTooLong) {
+		return nil, nil
+	}
+	return segs, err
+}
+
+// trimURLCut removes an incomplete percent-escape and dangling query or fragment separators
+// from the end of a truncated URL.
+func trimURLCut(url string) string {
+	if i := strings.LastIndexByte(url, '%'); i != -1 && i >= len(url)-2 {
+		url = url[:i]
+	}
+	return strings.TrimRight(url, "?&#")
+}
@@ -0,0 +1,134 @@
+package go_qr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateToFit(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		ecl        Ecc
+		maxVersion int
+		options    []TruncateOption
+		wantErr    bool
+		wantText   string
+		wantSuffix string
+	}{
+		{
+			name:       "text fits unchanged",
+			text:       "Hello, world!",
+			ecl:        Low,
+			maxVersion: 1,
+			wantText:   "Hello, world!",
+		},
+		{
+			name:       "empty text",
+			text:       "",
+			ecl:        High,
+			maxVersion: 1,
+			wantText:   "",
+		},
+		{
+			name:       "byte text truncated",
+			text:       strings.Repeat("lorem ipsum ", 10),
+			ecl:        Low,
+			maxVersion: 1,
+			wantText:   "lorem ipsum lorem",
+		},
+		{
+			name:       "kanji text truncated",
+			text:       strings.Repeat("魔法少女", 10),
+			ecl:        Low,
+			maxVersion: 1,
+			wantText:   "魔法少女魔法少女魔法",
+		},
+		{
+			name:       "greek text truncated on rune boundary",
+			text:       strings.Repeat("αβγδ", 10),
+			ecl:        Medium,
+			maxVersion: 1,
+			wantText:   "αβγδαβγδ",
+		},
+		{
+			name:       "truncated with ellipsis",
+			text:       strings.Repeat("lorem ipsum ", 10),
+			ecl:        Low,
+			maxVersion: 1,
+			options:    []TruncateOption{WithEllipsis("...")},
+			wantText:   "lorem ipsum lo...",
+			wantSuffix: "...",
+		},
+		{
+			name:       "ellipsis too long",
+			text:       strings.Repeat("lorem ipsum ", 10),
+			ecl:        High,
+			maxVersion: 1,
+			options:    []TruncateOption{WithEllipsis(strings.Repeat(".", 20))},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid max version",
+			text:       "Hello, world!",
+			ecl:        Low,
+			maxVersion: 41,
+			wantErr:    true,
+		},
+		{
+			name:       "invalid UTF-8",
+			text:       "\xff\xfe",
+			ecl:        Low,
+			maxVersion: 1,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, segs, err := TruncateToFit(tt.text, tt.ecl, tt.maxVersion, tt.options...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TruncateToFit() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			assert.Equal(t, tt.wantText, got)
+			assert.True(t, utf8.ValidString(got))
+			assert.True(t, strings.HasPrefix(tt.text, strings.TrimSuffix(got, tt.wantSuffix)))
+
+			_, err = EncodeSegments(segs, tt.ecl, MinVersion, tt.maxVersion, -1, false)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTruncateToFit_DataTooLong(t *testing.T) {
+	_, _, err := TruncateToFit(strings.Repeat("lorem ipsum ", 10), High, 1, WithEllipsis(strings.Repeat(".", 20)))
+	var dataTooLong *DataTooLongException
+	assert.True(t, errors.As(err, &dataTooLong))
+}
+
+func TestTrimURLCut(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://example.com/a%2", want: "https://example.com/a"},
+		{url: "https://example.com/a%", want: "https://example.com/a"},
+		{url: "https://example.com/a%2F", want: "https://example.com/a%2F"},
+		{url: "https://example.com/?a=1&", want: "https://example.com/?a=1"},
+		{url: "https://example.com/?", want: "https://example.com/"},
+		{url: "https://example.com/page#", want: "https://example.com/page"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, trimURLCut(tt.url))
+	}
+}